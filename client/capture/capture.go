@@ -0,0 +1,274 @@
+// Package capture implements a client.Caller for testing that
+// captures requests.
+/*
+The Caller records the method, endpoint, headers, query,
+form values and multipart files of every request, then
+forwards the request to a fake.Server so responses are
+realistic. Tests can assert on what was sent:
+
+	c := capture.New(t)
+	if _, err := msg.Send(c); err != nil {
+		t.Fatal(err)
+	}
+	c.AssertSent(t, `rick@roll.net`, `Hey bud!`)
+	vars, err := c.LastMessage().RecipientVariables()
+*/
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/fake"
+)
+
+// File is a captured multipart file part.
+type File struct {
+	Field  string
+	Name   string
+	Header textproto.MIMEHeader
+	Data   []byte
+}
+
+// Request is a captured request. Form holds urlencoded or
+// multipart form values, Body holds any other payload.
+type Request struct {
+	Method   string
+	Endpoint string
+	Path     string
+	Header   http.Header
+	Query    url.Values
+	Form     url.Values
+	Files    []File
+	Body     []byte
+}
+
+// Recipients returns the to, cc and bcc form values of r.
+func (r *Request) Recipients() []string {
+	var rcpts []string
+	for _, k := range []string{`to`, `cc`, `bcc`} {
+		rcpts = append(rcpts, r.Form[k]...)
+	}
+	return rcpts
+}
+
+// Subject returns the subject form value of r, or for a
+// messages.mime send the decoded Subject header of its
+// message part.
+func (r *Request) Subject() string {
+	if v, ok := r.Form[`subject`]; ok {
+		return v[0]
+	}
+	for _, f := range r.Files {
+		if f.Field != `message` {
+			continue
+		}
+		msg, err := mail.ReadMessage(bytes.NewReader(f.Data))
+		if err != nil {
+			return ``
+		}
+		v := msg.Header.Get(`Subject`)
+		if d, err := new(mime.WordDecoder).DecodeHeader(v); err == nil {
+			v = d
+		}
+		return v
+	}
+	return ``
+}
+
+// RecipientVariables decodes the recipient-variables
+// form value of r, nil if absent.
+func (r *Request) RecipientVariables() (map[string]map[string]interface{}, error) {
+	v := r.Form.Get(`recipient-variables`)
+	if len(v) == 0 {
+		return nil, nil
+	}
+	var m map[string]map[string]interface{}
+	return m, json.Unmarshal([]byte(v), &m)
+}
+
+// Caller is a client.Caller capturing requests.
+type Caller struct {
+	*client.Requester
+	server   *fake.Server
+	mu       sync.Mutex
+	requests []*Request
+}
+
+var _ = client.Caller(&Caller{})
+
+// New returns a Caller forwarding to a new fake.Server.
+func New(t testing.TB) *Caller {
+	s := fake.New(t)
+	c := &Caller{server: s}
+	c.Requester = client.New(s.Endpoint(), fake.Key, fake.Domain)
+	c.Requester.Client = &http.Client{Transport: c}
+	return c
+}
+
+// Server returns the fake.Server requests are forwarded to.
+func (c *Caller) Server() *fake.Server {
+	return c.server
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *Caller) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, body, err := capture(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, r)
+	c.mu.Unlock()
+	out := req.Clone(req.Context())
+	out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	return http.DefaultTransport.RoundTrip(out)
+}
+
+func capture(req *http.Request) (*Request, []byte, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	r := &Request{
+		Method:   req.Method,
+		Endpoint: req.URL.String(),
+		Path:     req.URL.Path,
+		Header:   req.Header.Clone(),
+		Query:    req.URL.Query(),
+		Form:     make(url.Values),
+	}
+	mt, params, _ := mime.ParseMediaType(req.Header.Get(`Content-Type`))
+	switch mt {
+	case `multipart/form-data`:
+		mr := multipart.NewReader(bytes.NewReader(body), params[`boundary`])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			b, err := ioutil.ReadAll(p)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(p.FileName()) > 0 {
+				r.Files = append(r.Files, File{Field: p.FormName(), Name: p.FileName(), Header: p.Header, Data: b})
+				continue
+			}
+			r.Form.Add(p.FormName(), string(b))
+		}
+	case `application/x-www-form-urlencoded`:
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Form = form
+	default:
+		r.Body = body
+	}
+	return r, body, nil
+}
+
+// Requests returns the captured requests, in order.
+func (c *Caller) Requests() []*Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Request{}, c.requests...)
+}
+
+// Last returns the last captured request, nil if none.
+func (c *Caller) Last() *Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.requests) == 0 {
+		return nil
+	}
+	return c.requests[len(c.requests)-1]
+}
+
+// Reset discards the captured requests.
+func (c *Caller) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = nil
+}
+
+func ismessage(r *Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	switch r.Path[strings.LastIndexByte(r.Path, '/')+1:] {
+	case `messages`, `messages.mime`:
+		return true
+	}
+	return false
+}
+
+// Messages returns the captured message sends, in order.
+func (c *Caller) Messages() []*Request {
+	var ms []*Request
+	for _, r := range c.Requests() {
+		if ismessage(r) {
+			ms = append(ms, r)
+		}
+	}
+	return ms
+}
+
+// LastMessage returns the last captured message send,
+// nil if none.
+func (c *Caller) LastMessage() *Request {
+	ms := c.Messages()
+	if len(ms) == 0 {
+		return nil
+	}
+	return ms[len(ms)-1]
+}
+
+// address returns the bare address of s.
+func address(s string) string {
+	if a, err := mail.ParseAddress(s); err == nil {
+		return a.Address
+	}
+	return s
+}
+
+// AssertSent fails t unless a message with subject was
+// sent to the address to, as a to, cc or bcc recipient.
+// Messages sent through messages.mime match by the Subject
+// header of the message.
+func (c *Caller) AssertSent(t testing.TB, to, subject string) {
+	t.Helper()
+	var sent []string
+	for _, m := range c.Messages() {
+		for _, r := range m.Recipients() {
+			if address(r) == address(to) && m.Subject() == subject {
+				return
+			}
+			sent = append(sent, fmt.Sprintf(`%q to %s`, m.Subject(), r))
+		}
+	}
+	t.Errorf("capture: no message %q sent to %s, sent: %v", subject, to, sent)
+}
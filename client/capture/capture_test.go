@@ -0,0 +1,79 @@
+package capture_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/j7b/mailgun/client/capture"
+	"github.com/j7b/mailgun/event"
+	"github.com/j7b/mailgun/message"
+	"github.com/j7b/mailgun/message/batch"
+)
+
+func TestMessage(t *testing.T) {
+	c := capture.New(t)
+	msg, err := message.New(`Mailgun <postmaster@domain.fake>`, `Hey bud!`, `<b>hi</b>`, `Rick <rick@roll.net>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Headers.Set(`x-campaign`, `summer`)
+	if _, err = msg.Send(c); err != nil {
+		t.Fatal(err)
+	}
+	c.AssertSent(t, `rick@roll.net`, `Hey bud!`)
+	m := c.LastMessage()
+	if m.Form.Get(`h:X-Campaign`) != `summer` {
+		t.Fatal(`unexpected headers`, m.Form)
+	}
+	if len(c.Server().Messages()) != 1 {
+		t.Fatal(`request not forwarded`)
+	}
+	raw, err := msg.MIME()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mime, err := message.NewMIME(`morty@roll.net`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mime.SendMIME(c, bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+	c.AssertSent(t, `morty@roll.net`, `Hey bud!`)
+}
+
+func TestBatch(t *testing.T) {
+	c := capture.New(t)
+	msg, err := message.New(`postmaster@domain.fake`, `Hi %recipient.name%`, `<b>hi</b>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recips := map[string]map[string]interface{}{
+		`a@example.com`: {`name`: `A`},
+		`b@example.com`: {`name`: `B`},
+	}
+	if _, err = batch.Send(c, msg, recips); err != nil {
+		t.Fatal(err)
+	}
+	vars, err := c.LastMessage().RecipientVariables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars[`b@example.com`][`name`] != `B` || len(vars) != 2 {
+		t.Fatal(`unexpected recipient-variables`, vars)
+	}
+	if n := len(c.LastMessage().Recipients()); n != 2 {
+		t.Fatal(`want 2 recipients got`, n)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	c := capture.New(t)
+	if _, err := event.Queries(c).Query(nil, nil, nil, event.Event(`delivered`), event.Recipient(`a@example.com`)); err != nil {
+		t.Fatal(err)
+	}
+	q := c.Last().Query
+	if q.Get(`event`) != `delivered` || q.Get(`recipient`) != `a@example.com` || q.Get(`limit`) != `300` {
+		t.Fatal(`unexpected query`, q)
+	}
+}
@@ -0,0 +1,332 @@
+// Package cassette implements a record/replay http.RoundTripper.
+/*
+A Recorder in Record mode performs requests with its Transport
+and keeps each request and response, Save writes them to a
+cassette file. In Replay mode the Recorder answers requests
+from the cassette without network access. Recorded requests
+do not include the Authorization header, and the API key, if
+provided, is replaced wherever it appears.
+
+	rec, err := cassette.New(`testdata/bounces.json`, cassette.Replay)
+	...
+	requester.Client = rec.Client()
+
+Requests are matched by method, path and normalized query
+and form values, multipart bodies are compared by their
+decoded parts so differing boundaries still match. Matching
+may be changed by setting Match.
+*/
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+// Recorder modes.
+const (
+	Replay Mode = iota
+	Record
+)
+
+// Request is a recorded request. Form holds the decoded
+// urlencoded or multipart body, file parts as
+// "filename sha256:hex", Body holds any other body.
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  url.Values  `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Form   url.Values  `json:"form,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status     string      `json:"status"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Matcher reports whether req matches recorded.
+type Matcher func(req, recorded *Request) bool
+
+// DefaultMatcher matches method, path, query, form and body.
+func DefaultMatcher(req, recorded *Request) bool {
+	return req.Method == recorded.Method &&
+		req.Path == recorded.Path &&
+		req.Query.Encode() == recorded.Query.Encode() &&
+		req.Form.Encode() == recorded.Form.Encode() &&
+		req.Body == recorded.Body
+}
+
+// Recorder is an http.RoundTripper that records or
+// replays interactions. Key, if not zero-length, is
+// replaced in recorded interactions. Transport performs
+// requests when recording, http.DefaultTransport if nil.
+type Recorder struct {
+	Mode      Mode
+	Path      string
+	Key       string
+	Transport http.RoundTripper
+	Match     Matcher
+	mu        sync.Mutex
+	recorded  []*Interaction
+	used      []bool
+}
+
+// New returns a Recorder for the cassette at path. In
+// Replay mode the cassette is loaded.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{Mode: mode, Path: path}
+	if mode != Replay {
+		return r, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &r.recorded); err != nil {
+		return nil, fmt.Errorf("cassette: %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.recorded))
+	return r, nil
+}
+
+// Client returns an *http.Client using r.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the interactions of r.
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction{}, r.recorded...)
+}
+
+// Save writes the interactions of r to its cassette.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, err := json.MarshalIndent(r.recorded, ``, `  `)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Path, b, 0644)
+}
+
+func (r *Recorder) scrub(s string) string {
+	if len(r.Key) == 0 {
+		return s
+	}
+	return strings.ReplaceAll(s, r.Key, `[redacted]`)
+}
+
+func (r *Recorder) scrubvalues(v url.Values) url.Values {
+	if len(v) == 0 {
+		return nil
+	}
+	o := make(url.Values, len(v))
+	for k, vs := range v {
+		for _, s := range vs {
+			o.Add(r.scrub(k), r.scrub(s))
+		}
+	}
+	return o
+}
+
+func (r *Recorder) scrubheader(h http.Header) http.Header {
+	o := make(http.Header)
+	for k, vs := range h {
+		if k == `Authorization` {
+			continue
+		}
+		for _, s := range vs {
+			o.Add(k, r.scrub(s))
+		}
+	}
+	return o
+}
+
+// normalize returns the recorded form of req and its body.
+func (r *Recorder) normalize(req *http.Request) (*Request, []byte, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	rec := &Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  r.scrubvalues(req.URL.Query()),
+		Header: r.scrubheader(req.Header),
+	}
+	if len(body) == 0 {
+		return rec, body, nil
+	}
+	mt, params, _ := mime.ParseMediaType(req.Header.Get(`Content-Type`))
+	switch mt {
+	case `multipart/form-data`:
+		form, err := parts(body, params[`boundary`])
+		if err != nil {
+			return nil, nil, err
+		}
+		rec.Form = r.scrubvalues(form)
+	case `application/x-www-form-urlencoded`:
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		rec.Form = r.scrubvalues(form)
+	default:
+		rec.Body = r.scrub(string(body))
+	}
+	for _, vs := range rec.Form {
+		sort.Strings(vs)
+	}
+	rec.Header.Del(`Content-Type`)
+	rec.Header.Del(`Content-Length`)
+	return rec, body, nil
+}
+
+// parts decodes a multipart body, file parts as their
+// file name and digest.
+func parts(body []byte, boundary string) (url.Values, error) {
+	form := make(url.Values)
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		v := string(b)
+		if len(p.FileName()) > 0 {
+			v = fmt.Sprintf(`%s sha256:%x`, p.FileName(), sha256.Sum256(b))
+		}
+		form.Add(p.FormName(), v)
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec, body, err := r.normalize(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.Mode == Record {
+		return r.record(req, rec, body)
+	}
+	return r.replay(req, rec)
+}
+
+func (r *Recorder) record(req *http.Request, rec *Request, body []byte) (*http.Response, error) {
+	t := r.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	out := req.Clone(req.Context())
+	out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	res, err := t.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(b))
+	r.mu.Lock()
+	r.recorded = append(r.recorded, &Interaction{
+		Request: *rec,
+		Response: Response{
+			Status:     res.Status,
+			StatusCode: res.StatusCode,
+			Header:     r.scrubheader(res.Header),
+			Body:       r.scrub(string(b)),
+		},
+	})
+	r.used = append(r.used, true)
+	r.mu.Unlock()
+	return res, nil
+}
+
+// replay answers req with the first unused matching
+// interaction, failing that the last matching one.
+func (r *Recorder) replay(req *http.Request, rec *Request) (*http.Response, error) {
+	match := r.Match
+	if match == nil {
+		match = DefaultMatcher
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := -1
+	for i, in := range r.recorded {
+		if !match(rec, &in.Request) {
+			continue
+		}
+		found = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("cassette: no interaction for %s %s", req.Method, req.URL.Path)
+	}
+	r.used[found] = true
+	in := r.recorded[found].Response
+	return &http.Response{
+		Status:        in.Status,
+		StatusCode:    in.StatusCode,
+		Proto:         `HTTP/1.1`,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        in.Header.Clone(),
+		Body:          ioutil.NopCloser(strings.NewReader(in.Body)),
+		ContentLength: int64(len(in.Body)),
+		Request:       req,
+	}, nil
+}
+
+var _ = http.RoundTripper(&Recorder{})
+
+// Exists reports whether the cassette at path exists, for
+// choosing between Record and Replay.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,74 @@
+package cassette_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/cassette"
+	"github.com/j7b/mailgun/client/fake"
+	"github.com/j7b/mailgun/message"
+	"github.com/j7b/mailgun/suppression/bounce"
+)
+
+func exercise(t *testing.T, c client.Caller) string {
+	api := bounce.Bounces(c)
+	if err := api.Add(`a@example.com`, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := api.Get(`a@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Address != `a@example.com` {
+		t.Fatal(`unexpected bounce`, b)
+	}
+	msg, err := message.New(`sender@domain.fake`, `Hello`, `<b>hi</b>`, `a@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Attachments[`a.txt`] = strings.NewReader(`attached`)
+	res, err := msg.Send(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.ID
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `cassette.json`)
+	s := fake.New(t)
+	rec, err := cassette.New(path, cassette.Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Key = fake.Key
+	c := client.New(s.Endpoint(), fake.Key, fake.Domain)
+	c.Client = rec.Client()
+	id := exercise(t, c)
+	if err = rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), fake.Key) {
+		t.Fatal(`API key recorded`)
+	}
+	rep, err := cassette.New(path, cassette.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = client.New(`https://api.mailgun.net/v3/`, fake.Key, fake.Domain)
+	c.Client = rep.Client()
+	if got := exercise(t, c); got != id {
+		t.Fatal(`want`, id, `got`, got)
+	}
+	if err = bounce.Bounces(c).Delete(`b@example.com`); err == nil {
+		t.Fatal(`want error for unrecorded request`)
+	}
+}
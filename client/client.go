@@ -6,7 +6,7 @@ This package is generally not intended for package consumers.
 package client
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,9 +18,13 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // DEBUG enables debugging - requests logged, response copied to stderr.
+//
+// Deprecated: DEBUG affects every Requester, use the Debug or
+// Logging middleware.
 var DEBUG = false
 
 // Pager is implemented by pager types.
@@ -28,54 +32,27 @@ type Pager interface {
 	SetCaller(Caller)
 }
 
-func apierr(res *http.Response) error {
-	if res.StatusCode > 299 {
-		defer res.Body.Close()
-		buf := new(bytes.Buffer)
-		io.CopyN(buf, res.Body, 80)
-		return Error{
-			Status:     res.Status,
-			StatusCode: res.StatusCode,
-			Stringer:   buf,
-		}
-	}
-	return nil
-}
-
-// Error is an HTTP error from the endpoint.
-type Error struct {
-	Status     string
-	StatusCode int
-	fmt.Stringer
-}
-
-// Err returns *Error if e is
-// Error, nil otherwise.
-func Err(e error) *Error {
-	switch t := e.(type) {
-	case Error:
-		return &t
-	case *Error:
-		return t
-	}
-	return nil
-}
-
-// Error implements error.
-func (e Error) Error() string {
-	return fmt.Sprintf("(%v) %s", e.StatusCode, e.Status)
-}
-
 func postHeader(h http.Header, boundary string) {
 	h.Set("Content-Type", fmt.Sprintf(`multipart/form-data; boundary=%s`, boundary))
 }
 
-// Requester has methods that return Requests.
+// Requester has methods that return Requests. If Retry
+// is not nil, Requests are retried according to its policy.
+// If Limit is not nil, each attempt waits for the Limiter.
+// Each attempt is performed through Middleware, the first
+// element outermost. If Instrument is not nil it observes
+// each performed Request. If Tracer is not nil it traces
+// each performed Request, including any retries.
 type Requester struct {
 	Endpoint  string
 	APIKey    string
 	APIDomain string
 	*http.Client
+	Retry      *Retry
+	Limit      *Limiter
+	Middleware []Middleware
+	Instrument Instrument
+	Tracer     Tracer
 }
 
 var _ = Caller(&Requester{})
@@ -95,6 +72,27 @@ func (r *Requester) Key() string {
 	return r.APIKey
 }
 
+func (r *Requester) handler(client *http.Client) Handler {
+	h := Handler(client.Do)
+	if DEBUG {
+		h = Debug(log.Default())(h)
+	}
+	if r == nil {
+		return h
+	}
+	for i := len(r.Middleware) - 1; i >= 0; i-- {
+		h = r.Middleware[i](h)
+	}
+	return h
+}
+
+func (r *Requester) limiter() *Limiter {
+	if r == nil {
+		return nil
+	}
+	return r.Limit
+}
+
 // HTTPClient returns the *http.Client associated with this Requester.
 func (r *Requester) HTTPClient() *http.Client {
 	if r.Client != nil {
@@ -105,14 +103,22 @@ func (r *Requester) HTTPClient() *http.Client {
 
 // Request is a client request.
 type Request struct {
-	client   Caller
-	e        error
-	endpoint string
-	method   string
-	header   http.Header
-	query    url.Values
-	form     url.Values
-	payload  io.Reader
+	client    Caller
+	requester *Requester
+	multipart func(*multipart.Writer) error
+	ctx       context.Context
+	retry     *bool
+	offset    int64
+	family    string
+	route     string
+	e         error
+	endpoint  string
+	method    string
+	header    http.Header
+	query     url.Values
+	form      url.Values
+	payload   io.Reader
+	consumed  *bool // payload that can't be seeked was sent, shared by clones
 }
 
 func (r *Request) err(e error) error {
@@ -122,9 +128,122 @@ func (r *Request) err(e error) error {
 	return e
 }
 
-func (r *Request) done() {
-	if r.e == nil {
-		r.e = fmt.Errorf("request: done")
+// WithContext sets the context.Context for this Request, returning Request.
+// Cancellation and deadlines of ctx apply to the underlying http.Request.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	if ctx == nil {
+		r.err(fmt.Errorf("request: nil context"))
+		return r
+	}
+	r.ctx = ctx
+	return r
+}
+
+// Context returns the context.Context for this Request.
+func (r *Request) Context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
+}
+
+// Retryable sets whether this Request may be retried under the
+// Requester's Retry policy, overriding the default for its method,
+// returning Request. POST requests must opt in to be retried unless
+// the policy retries all POST requests.
+func (r *Request) Retryable(b bool) *Request {
+	r.retry = &b
+	return r
+}
+
+func (r *Request) attempts() int {
+	if r.requester == nil || r.requester.Retry == nil {
+		return 1
+	}
+	p := r.requester.Retry
+	if r.retry != nil {
+		if !*r.retry {
+			return 1
+		}
+	} else if !p.method(r.method) {
+		return 1
+	}
+	return p.attempts()
+}
+
+// replayable reports whether the body of req can be
+// read again on each attempt.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// getbody returns a func returning the payload of r from
+// the offset it had when set, nil if it can't be seeked.
+func (r *Request) getbody() func() (io.ReadCloser, error) {
+	s, ok := r.payload.(io.Seeker)
+	if !ok || r.offset < 0 {
+		return nil
+	}
+	return func() (io.ReadCloser, error) {
+		if _, err := s.Seek(r.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(r.payload), nil
+	}
+}
+
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	return next, nil
+}
+
+// send performs req, retrying according to policy. The
+// body of the last attempt is closed on return, so a body
+// written by a goroutine is never left blocked.
+func (r *Request) send(client *http.Client, req *http.Request) (res *http.Response, err error) {
+	start, retries := time.Now(), 0
+	defer func() {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		r.observe(start, retries, res, err)
+	}()
+	attempts := r.attempts()
+	if attempts > 1 && !replayable(req) {
+		attempts = 1
+	}
+	h := r.requester.handler(client)
+	for i := 1; ; i++ {
+		retries = i - 1
+		if l := r.requester.limiter(); l != nil {
+			if err := l.Wait(req.Context(), r.family); err != nil {
+				return nil, err
+			}
+		}
+		res, err = h(req)
+		if i >= attempts || !retryable(req.Context(), res, err) {
+			return res, err
+		}
+		wait := r.requester.Retry.backoff(i - 1)
+		if after := retryafter(res); after > wait {
+			wait = after
+		}
+		discard(res)
+		if err = sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		next, err := rewind(req)
+		if err != nil {
+			return nil, err
+		}
+		req = next
 	}
 }
 
@@ -189,9 +308,21 @@ func (r *Request) AddForm(k, v string) *Request {
 }
 
 // Payload sets the io.Reader for this Request. Precludes
-// Form method.
+// Form and Multipart methods. If reader is an io.Seeker the
+// Request may be performed more than once, each time reading
+// from the current offset of reader, otherwise performing it
+// again fails with ErrNotReplayable.
 func (r *Request) Payload(reader io.Reader) *Request {
 	r.payload = reader
+	r.offset, r.consumed = -1, nil
+	if reader != nil {
+		r.consumed = new(bool)
+	}
+	if s, ok := reader.(io.Seeker); ok {
+		if offset, err := s.Seek(0, io.SeekCurrent); err == nil {
+			r.offset = offset
+		}
+	}
 	return r
 }
 
@@ -199,7 +330,7 @@ func (r *Request) Payload(reader io.Reader) *Request {
 func (r *Request) Err() error {
 	res, err := r.Do()
 	if err != nil {
-		return r.err(err)
+		return err
 	}
 	return res.Body.Close()
 }
@@ -212,6 +343,9 @@ func (r *Request) setpager(i interface{}) {
 	default:
 		return
 	}
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return
+	}
 	if sc, ok := v.Interface().(Pager); ok {
 		sc.SetCaller(r.client)
 	}
@@ -221,86 +355,225 @@ func (r *Request) setpager(i interface{}) {
 func (r *Request) Decode(i interface{}) error {
 	res, err := r.Do()
 	if err != nil {
-		return r.err(err)
-
+		return err
 	}
 	defer res.Body.Close()
-	if err = json.NewDecoder(res.Body).Decode(i); err != nil {
-		return r.err(err)
+	return r.decode(res, i)
+}
+
+func (r *Request) decode(res *http.Response, i interface{}) error {
+	if err := json.NewDecoder(res.Body).Decode(i); err != nil {
+		return err
 	}
 	r.setpager(i)
 	return nil
 }
 
-// Do performs this Request.
-func (r *Request) Do() (*http.Response, error) {
-	defer r.done()
+// Clone returns a copy of r with its own headers, query
+// and form values. The copy shares any payload or
+// multipart func of r.
+func (r *Request) Clone() *Request {
+	c := *r
+	c.header = r.header.Clone()
+	c.query = clonevalues(r.query)
+	c.form = clonevalues(r.form)
+	if r.retry != nil {
+		b := *r.retry
+		c.retry = &b
+	}
+	return &c
+}
+
+func clonevalues(v url.Values) url.Values {
+	if v == nil {
+		return nil
+	}
+	return url.Values(http.Header(v).Clone())
+}
+
+// URL returns the URL this Request is performed with,
+// including the query string.
+func (r *Request) URL() string {
+	if len(r.query) == 0 {
+		return r.endpoint
+	}
+	return fmt.Sprintf(`%s?%s`, r.endpoint, r.query.Encode())
+}
+
+// String returns the method and URL of this Request.
+func (r *Request) String() string {
+	return fmt.Sprintf(`%s %s`, r.method, r.URL())
+}
+
+// HTTPRequest builds the *http.Request for this Request
+// without performing it. Each call builds a new one, so
+// this Request may be performed more than once, unless its
+// payload can't be read again (see Payload). The Body
+// of the *http.Request must be closed if it isn't sent.
+func (r *Request) HTTPRequest() (*http.Request, error) {
+	return r.build(r.Context())
+}
+
+func (r *Request) build(ctx context.Context) (*http.Request, error) {
 	if r.e != nil {
 		return nil, r.e
 	}
-	client := r.client.HTTPClient()
-	if len(r.query) > 0 {
-		r.endpoint += fmt.Sprintf(`?%s`, r.query.Encode())
-	}
-	if len(r.form) > 0 {
-		buf := new(bytes.Buffer)
-		w := multipart.NewWriter(buf)
-		for k, v := range r.form {
-			for _, v := range v {
-				if err := w.WriteField(k, v); err != nil {
-					return nil, r.err(err)
-				}
+	if len(r.form) > 0 || r.multipart != nil {
+		return r.multipartrequest(ctx)
+	}
+	body, length := r.payload, int64(-1)
+	getbody := r.getbody()
+	switch {
+	case getbody != nil:
+		s := r.payload.(io.Seeker)
+		if _, ok := body.(io.Closer); ok {
+			// the transport closes the body, keep it open
+			// for the next attempt.
+			body = ioutil.NopCloser(body)
+			end, err := s.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
 			}
+			length = end - r.offset
 		}
-		if err := w.Close(); err != nil {
-			return nil, r.err(err)
+		if _, err := s.Seek(r.offset, io.SeekStart); err != nil {
+			return nil, err
 		}
-		if r.header == nil {
-			r.header = make(http.Header)
+	case r.consumed != nil:
+		if *r.consumed {
+			return nil, ErrNotReplayable
 		}
-		postHeader(r.header, w.Boundary())
-		r.payload = buf
+		*r.consumed = true
 	}
-	if DEBUG {
-		log.Println("method", r.method)
-		log.Println("endpoint", r.endpoint)
-		log.Printf("payload: %T", r.payload)
+	req, err := http.NewRequestWithContext(ctx, r.method, r.URL(), body)
+	if err != nil {
+		return nil, err
+	}
+	if length >= 0 {
+		req.ContentLength = length
 	}
-	req, err := http.NewRequest(r.method, r.endpoint, r.payload)
+	if req.GetBody == nil {
+		req.GetBody = getbody
+	}
+	r.setheader(req)
+	return req, nil
+}
+
+func (r *Request) multipartrequest(ctx context.Context) (*http.Request, error) {
+	getbody, boundary := r.stream()
+	req, err := http.NewRequestWithContext(ctx, r.method, r.URL(), nil)
 	if err != nil {
-		return nil, r.err(err)
+		return nil, err
+	}
+	if req.Body, err = getbody(); err != nil {
+		return nil, err
 	}
+	req.GetBody = getbody
+	r.setheader(req)
+	postHeader(req.Header, boundary)
+	return req, nil
+}
+
+func (r *Request) setheader(req *http.Request) {
 	for k, v := range r.header {
-		req.Header[k] = v
+		req.Header[k] = append([]string(nil), v...)
 	}
 	req.SetBasicAuth("api", r.client.Key())
-	res, err := client.Do(req)
+}
+
+// Do performs this Request. A Request may be performed
+// more than once unless its payload can be read only once,
+// then Do fails with ErrNotReplayable.
+func (r *Request) Do() (res *http.Response, err error) {
+	ctx := r.Context()
+	t := r.requester.tracer()
+	if t != nil {
+		var span Span
+		ctx, span = r.start(t, ctx)
+		defer func() {
+			end(span, res, err)
+		}()
+	}
+	req, err := r.build(ctx)
 	if err != nil {
-		return nil, r.err(err)
+		return nil, err
 	}
-	if err = apierr(res); err != nil {
-		return nil, r.err(err)
+	if in, ok := t.(Injector); ok {
+		in.Inject(ctx, req.Header)
 	}
-	if DEBUG {
-		defer res.Body.Close()
-		buf := new(bytes.Buffer)
-		if _, err = io.Copy(buf, res.Body); err != nil {
-			panic(err)
-		}
-		log.Println(buf.String())
-		res.Body = ioutil.NopCloser(buf)
+	res, err = r.send(r.client.HTTPClient(), req)
+	if err != nil {
+		return nil, err
+	}
+	if err = apierr(res); err != nil {
+		return nil, err
 	}
 	return res, nil
 }
 
+func absolute(pth string) bool {
+	return strings.HasPrefix(pth, `https:/`) || strings.HasPrefix(pth, `http:/`)
+}
+
 func join(uri []string) string {
 	pth := path.Join(uri...)
-	if strings.HasPrefix(pth, `https:/`) {
-		pth = strings.Replace(pth, `https:/`, `https://`, 1)
+	for _, scheme := range []string{`https:/`, `http:/`} {
+		if strings.HasPrefix(pth, scheme) && !strings.HasPrefix(pth, scheme+`/`) {
+			pth = strings.Replace(pth, scheme, scheme+`/`, 1)
+		}
 	}
 	return pth
 }
 
+// origin returns the scheme and host of an absolute
+// URL, zero-length if u isn't absolute.
+func origin(u string) string {
+	i := strings.Index(u, `://`)
+	if i < 0 || !absolute(u) {
+		return ``
+	}
+	if j := strings.IndexByte(u[i+3:], '/'); j >= 0 {
+		return u[:i+3+j]
+	}
+	return u
+}
+
+// root splits an absolute URL before the first API version
+// segment of its path, false if it has none.
+func root(u string) (string, string, bool) {
+	o := origin(u)
+	pth := u[len(o):]
+	if i := strings.IndexAny(pth, `?#`); i >= 0 {
+		pth = pth[:i]
+	}
+	n := len(o)
+	for _, seg := range strings.Split(pth, `/`)[1:] {
+		if isversion(seg) {
+			return u[:n], u[n:], true
+		}
+		n += 1 + len(seg)
+	}
+	return o, u[len(o):], false
+}
+
+// rebase moves an absolute URL, such as a paging URL, to
+// the Endpoint, replacing its scheme, host and any path
+// before the API version with those of the Endpoint.
+func (r *Requester) rebase(u string) string {
+	if len(origin(r.Endpoint)) == 0 || len(origin(u)) == 0 || strings.HasPrefix(u, r.Endpoint) {
+		return u
+	}
+	to, _, ok := root(r.Endpoint)
+	if !ok {
+		to = strings.TrimSuffix(r.Endpoint, `/`)
+	}
+	from, rest, _ := root(u)
+	if to == from {
+		return u
+	}
+	return to + rest
+}
+
 func (r *Requester) request(method string, uri ...string) *Request {
 	req := new(Request)
 	if len(uri) == 0 {
@@ -309,18 +582,35 @@ func (r *Requester) request(method string, uri ...string) *Request {
 	}
 	req.method = method
 	req.client = r
+	req.requester = r
 	pth := join(uri)
 	switch {
-	case strings.HasPrefix(pth, `https:/`):
-		req.endpoint = pth
+	case absolute(pth):
+		req.endpoint = r.rebase(pth)
 	case pth[0] == '/':
 		req.endpoint = r.Endpoint + pth[1:]
 	default:
 		req.endpoint = r.Endpoint + path.Join(r.APIDomain, pth)
 	}
+	rel := r.relative(req.endpoint)
+	req.family = family(rel, r.APIDomain)
+	req.route = route(rel)
 	return req
 }
 
+// relative returns endpoint relative to the API version root.
+func (r *Requester) relative(endpoint string) string {
+	if strings.HasPrefix(endpoint, r.Endpoint) {
+		return endpoint[len(r.Endpoint):]
+	}
+	pth := strings.TrimPrefix(endpoint, origin(endpoint))
+	segs := strings.SplitN(strings.TrimPrefix(pth, `/`), `/`, 2)
+	if len(segs) < 2 {
+		return pth
+	}
+	return segs[1]
+}
+
 // Get returns a GET Request.
 func (r *Requester) Get(uri ...string) *Request {
 	return r.request(http.MethodGet, uri...)
@@ -351,3 +641,43 @@ type Caller interface {
 	Domain() string
 	Key() string
 }
+
+type ctxcaller struct {
+	Caller
+	ctx context.Context
+}
+
+func (c ctxcaller) bind(r *Request) *Request {
+	r.client = c
+	return r.WithContext(c.ctx)
+}
+
+// Get returns a GET Request.
+func (c ctxcaller) Get(uri ...string) *Request {
+	return c.bind(c.Caller.Get(uri...))
+}
+
+// Post returns a POST Request.
+func (c ctxcaller) Post(uri ...string) *Request {
+	return c.bind(c.Caller.Post(uri...))
+}
+
+// Put returns a PUT Request.
+func (c ctxcaller) Put(uri ...string) *Request {
+	return c.bind(c.Caller.Put(uri...))
+}
+
+// Delete returns a DELETE Request.
+func (c ctxcaller) Delete(uri ...string) *Request {
+	return c.bind(c.Caller.Delete(uri...))
+}
+
+// WithContext returns a Caller whose Requests use ctx. Pagers
+// decoded by those Requests retain the returned Caller, so
+// subsequent pages also use ctx.
+func WithContext(c Caller, ctx context.Context) Caller {
+	if cc, ok := c.(ctxcaller); ok {
+		c = cc.Caller
+	}
+	return ctxcaller{Caller: c, ctx: ctx}
+}
@@ -0,0 +1,316 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func server(t *testing.T, h http.HandlerFunc) *Requester {
+	s := httptest.NewServer(h)
+	t.Cleanup(s.Close)
+	return New(s.URL+`/v3/`, `key`, `domain.test`)
+}
+
+func TestWithContext(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.Write([]byte(`{}`))
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var o struct{}
+	err := WithContext(c, ctx).Get(`bounces`).Decode(&o)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(`want`, context.DeadlineExceeded, `got`, err)
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Get(`bounces`).WithContext(ctx).Err(); !errors.Is(err, context.Canceled) {
+		t.Fatal(`want`, context.Canceled, `got`, err)
+	}
+	if err := c.Get(`bounces`).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func flaky(t *testing.T, fail int, bodies *[]string) (*Requester, *int) {
+	n := new(int)
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		*n++
+		if bodies != nil {
+			r.ParseMultipartForm(1 << 20)
+			*bodies = append(*bodies, r.FormValue(`a`))
+		}
+		if *n <= fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	c.Retry = &Retry{Attempts: 3, Min: time.Millisecond, Max: 2 * time.Millisecond}
+	return c, n
+}
+
+func TestRetry(t *testing.T) {
+	c, n := flaky(t, 2, nil)
+	if err := c.Get(`bounces`).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if *n != 3 {
+		t.Fatal(`want 3 attempts got`, *n)
+	}
+	c, n = flaky(t, 3, nil)
+	err := c.Delete(`bounces`).Err()
+	if e := Err(err); e == nil || e.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal(`want 503 got`, err)
+	}
+	if *n != 3 {
+		t.Fatal(`want 3 attempts got`, *n)
+	}
+}
+
+func TestRetryPost(t *testing.T) {
+	var bodies []string
+	c, n := flaky(t, 1, &bodies)
+	if err := c.Post(`bounces`).SetForm(`a`, `b`).Err(); err == nil {
+		t.Fatal(`want error`)
+	}
+	if *n != 1 {
+		t.Fatal(`want 1 attempt got`, *n)
+	}
+	bodies = nil
+	c, n = flaky(t, 1, &bodies)
+	if err := c.Post(`bounces`).SetForm(`a`, `b`).Retryable(true).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if *n != 2 {
+		t.Fatal(`want 2 attempts got`, *n)
+	}
+	for _, b := range bodies {
+		if b != `b` {
+			t.Fatal(`want replayed body got`, bodies)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set(`Retry-After`, `7`)
+	if d := retryafter(res); d != 7*time.Second {
+		t.Fatal(`want 7s got`, d)
+	}
+	res.Header.Set(`Retry-After`, time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	if d := retryafter(res); d < 58*time.Second || d > time.Minute {
+		t.Fatal(`want ~1m got`, d)
+	}
+}
+
+func TestError(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(`Content-Type`, `application/json`)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Address not found in bounces table"}`))
+	})
+	err := c.Get(`bounces`, `a@b.c`).Err()
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(`want`, ErrNotFound, `got`, err)
+	}
+	if errors.Is(err, ErrRateLimited) {
+		t.Fatal(`unexpected`, ErrRateLimited)
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatal(`want *Error got`, err)
+	}
+	if e.Message != `Address not found in bounces table` {
+		t.Fatal(`unexpected message`, e.Message)
+	}
+	if e.Method != http.MethodGet || e.Endpoint != c.Endpoint+`domain.test/bounces/a@b.c` {
+		t.Fatal(`unexpected method or endpoint`, e.Method, e.Endpoint)
+	}
+	if e.Header.Get(`Content-Type`) != `application/json` {
+		t.Fatal(`headers not retained`)
+	}
+	if Err(err) == nil {
+		t.Fatal(`want Err non-nil`)
+	}
+}
+
+func TestRebase(t *testing.T) {
+	c := New(`https://api.eu.mailgun.net/v3/`, `key`, `domain.test`)
+	for _, tc := range []struct{ uri, want string }{
+		{`https://api.mailgun.net/v3/domain.test/bounces?page=next`, `https://api.eu.mailgun.net/v3/domain.test/bounces?page=next`},
+		{`http://127.0.0.1:1234/v3/lists/pages`, `https://api.eu.mailgun.net/v3/lists/pages`},
+		{`/lists/pages`, `https://api.eu.mailgun.net/v3/lists/pages`},
+		{`bounces`, `https://api.eu.mailgun.net/v3/domain.test/bounces`},
+	} {
+		if e := c.Get(tc.uri).endpoint; e != tc.want {
+			t.Fatal(`want`, tc.want, `got`, e)
+		}
+	}
+	c = New(`https://proxy.test/mailgun/v3/`, `key`, `domain.test`)
+	for _, tc := range []struct{ uri, want string }{
+		{`https://api.mailgun.net/v3/domain.test/bounces?page=next`, `https://proxy.test/mailgun/v3/domain.test/bounces?page=next`},
+		{`https://proxy.test/mailgun/v3/lists/pages`, `https://proxy.test/mailgun/v3/lists/pages`},
+		{`https://api.mailgun.net/v4/domains`, `https://proxy.test/mailgun/v4/domains`},
+		{`/lists/pages`, `https://proxy.test/mailgun/v3/lists/pages`},
+	} {
+		if e := c.Get(tc.uri).endpoint; e != tc.want {
+			t.Fatal(`want`, tc.want, `got`, e)
+		}
+	}
+	c = New(`https://proxy.test/mailgun/`, `key`, `domain.test`)
+	if e, want := c.Get(`https://api.mailgun.net/v3/lists/pages`).endpoint, `https://proxy.test/mailgun/v3/lists/pages`; e != want {
+		t.Fatal(`want`, want, `got`, e)
+	}
+}
+
+func TestWithDomain(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, `/v3/bad.test/`) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	})
+	c.Limit = NewLimiter(0, 0)
+	d := WithDomain(WithContext(c, context.Background()), `other.test`)
+	if d.Domain() != `other.test` || c.Domain() != `domain.test` {
+		t.Fatal(`want other.test and domain.test got`, d.Domain(), c.Domain())
+	}
+	if r, ok := d.(ctxcaller).Caller.(*Requester); !ok || r.Limit != c.Limit {
+		t.Fatal(`want Requester sharing limiter got`, d)
+	}
+	if err := d.Get(`bounces`).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithDomain(struct{ Caller }{c}, `third.test`).Get(`tags`).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if paths[0] != `/v3/other.test/bounces` || paths[1] != `/v3/third.test/tags` {
+		t.Fatal(`want domain paths got`, paths)
+	}
+	res := EachDomain(c, []string{`a.test`, `bad.test`, `b.test`}, 2, func(c Caller) (string, error) {
+		return c.Domain(), c.Get(`stats`).Err()
+	})
+	if f := res.Failed(); len(f) != 1 || f[0] != `bad.test` {
+		t.Fatal(`want bad.test failed got`, f)
+	}
+	if res[2].Value != `b.test` || !errors.Is(res.Err(), ErrNotFound) {
+		t.Fatal(`want b.test and`, ErrNotFound, `got`, res[2].Value, res.Err())
+	}
+}
+
+func TestReexecute(t *testing.T) {
+	var got []string
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, r.URL.RawQuery+` `+string(b))
+		w.Write([]byte(`{}`))
+	})
+	req := c.Post(`tags`).SetQuery(`a`, `1`).Payload(strings.NewReader(`body`))
+	for i := 0; i < 2; i++ {
+		if err := req.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clone := req.Clone().SetQuery(`a`, `2`)
+	if err := clone.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := c.Endpoint + `domain.test/tags?a=1`; req.URL() != want || req.String() != `POST `+want {
+		t.Fatal(`want`, want, `got`, req)
+	}
+	if len(got) != 3 || got[0] != `a=1 body` || got[1] != got[0] || got[2] != `a=2 body` {
+		t.Fatal(`want repeated requests got`, got)
+	}
+	f, err := os.CreateTemp(t.TempDir(), `payload`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(`file`); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	freq := c.Post(`tags`).Payload(f)
+	for i := 0; i < 2; i++ {
+		if err = freq.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 5 || got[3] != ` file` || got[4] != got[3] {
+		t.Fatal(`want file payload replayed got`, got)
+	}
+	got = got[:3]
+	once := c.Post(`tags`).Payload(io.MultiReader(strings.NewReader(`once`)))
+	if err := once.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := once.Clone().Err(); !errors.Is(err, ErrNotReplayable) {
+		t.Fatal(`want`, ErrNotReplayable, `got`, err)
+	}
+	if len(got) != 4 || got[3] != ` once` {
+		t.Fatal(`want one request with payload got`, got)
+	}
+	hr, err := c.Put(`tags`).SetForm(`k`, `v`).SetHeader(`X-Test`, `1`).HTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hr.Body.Close()
+	if err = hr.ParseMultipartForm(1 << 20); err != nil || hr.FormValue(`k`) != `v` {
+		t.Fatal(`want form value got`, hr.FormValue(`k`), err)
+	}
+	if _, _, ok := hr.BasicAuth(); !ok || hr.Header.Get(`X-Test`) != `1` || hr.GetBody == nil {
+		t.Fatal(`want auth, header and GetBody got`, hr.Header)
+	}
+}
+
+// seekfail is a payload that fails to seek after n seeks.
+type seekfail struct {
+	*strings.Reader
+	n int
+}
+
+func (s *seekfail) Seek(offset int64, whence int) (int64, error) {
+	if s.n--; s.n < 0 {
+		return 0, errors.New(`seek failed`)
+	}
+	return s.Reader.Seek(offset, whence)
+}
+
+func TestRewindError(t *testing.T) {
+	calls := 0
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte(`{}`))
+	})
+	c.Retry = &Retry{Attempts: 3, Min: time.Millisecond, Max: 2 * time.Millisecond, Post: true}
+	err := c.Post(`tags`).Payload(&seekfail{Reader: strings.NewReader(`body`), n: 2}).Err()
+	if err == nil || err.Error() != `seek failed` {
+		t.Fatal(`want seek failed got`, err)
+	}
+}
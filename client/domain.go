@@ -0,0 +1,137 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+)
+
+// WithDomain returns a copy of r for domain, sharing the
+// *http.Client, Retry, Limit and Middleware of r.
+func (r *Requester) WithDomain(domain string) *Requester {
+	c := *r
+	c.APIDomain = domain
+	return &c
+}
+
+type domaincaller struct {
+	Caller
+	domain string
+}
+
+// scope roots a domain-relative uri at the domain of c.
+func (c domaincaller) scope(uri []string) []string {
+	if len(uri) == 0 {
+		return uri
+	}
+	pth := join(uri)
+	if len(pth) == 0 || pth[0] == '/' || absolute(pth) {
+		return uri
+	}
+	return []string{`/`, path.Join(c.domain, pth)}
+}
+
+func (c domaincaller) bind(r *Request) *Request {
+	r.client = c
+	return r
+}
+
+// Domain returns the API domain.
+func (c domaincaller) Domain() string {
+	return c.domain
+}
+
+// Get returns a GET Request.
+func (c domaincaller) Get(uri ...string) *Request {
+	return c.bind(c.Caller.Get(c.scope(uri)...))
+}
+
+// Post returns a POST Request.
+func (c domaincaller) Post(uri ...string) *Request {
+	return c.bind(c.Caller.Post(c.scope(uri)...))
+}
+
+// Put returns a PUT Request.
+func (c domaincaller) Put(uri ...string) *Request {
+	return c.bind(c.Caller.Put(c.scope(uri)...))
+}
+
+// Delete returns a DELETE Request.
+func (c domaincaller) Delete(uri ...string) *Request {
+	return c.bind(c.Caller.Delete(c.scope(uri)...))
+}
+
+// WithDomain returns a Caller for domain that otherwise
+// behaves as c, sharing its *http.Client, limiter, middleware
+// and any context set by WithContext.
+func WithDomain(c Caller, domain string) Caller {
+	switch cc := c.(type) {
+	case *Requester:
+		return cc.WithDomain(domain)
+	case ctxcaller:
+		return ctxcaller{Caller: WithDomain(cc.Caller, domain), ctx: cc.ctx}
+	case domaincaller:
+		c = cc.Caller
+	}
+	return domaincaller{Caller: c, domain: domain}
+}
+
+// Result is the result of an operation on a domain.
+type Result[T any] struct {
+	Domain string
+	Value  T
+	Err    error
+}
+
+// Results are the results of an operation on domains,
+// in the order the domains were given.
+type Results[T any] []Result[T]
+
+// Err returns the errors of r joined, nil if none.
+func (r Results[T]) Err() error {
+	var errs []error
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Domain, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Failed returns the domains whose operation failed.
+func (r Results[T]) Failed() []string {
+	var ds []string
+	for _, res := range r {
+		if res.Err != nil {
+			ds = append(ds, res.Domain)
+		}
+	}
+	return ds
+}
+
+// EachDomain calls op with a Caller derived from c by
+// WithDomain for each of domains, at most n at once or
+// all at once if n < 1, and collects the results.
+func EachDomain[T any](c Caller, domains []string, n int, op func(c Caller) (T, error)) Results[T] {
+	if n < 1 || n > len(domains) {
+		n = len(domains)
+	}
+	res := make(Results[T], len(domains))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, d := range domains {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			v, err := op(WithDomain(c, d))
+			res[i] = Result[T]{Domain: d, Value: v, Err: err}
+		}()
+	}
+	wg.Wait()
+	return res
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Error classes. An Error matches its class with errors.Is.
+var (
+	ErrBadRequest      = errors.New("bad request")       // 400
+	ErrUnauthorized    = errors.New("unauthorized")      // 401 and 403
+	ErrNotFound        = errors.New("not found")         // 404
+	ErrPayloadTooLarge = errors.New("payload too large") // 413
+	ErrRateLimited     = errors.New("rate limited")      // 429
+	ErrServer          = errors.New("server error")      // 5xx
+)
+
+// ErrNotReplayable is returned performing a Request again
+// when its payload can't be read again.
+var ErrNotReplayable = errors.New("request: payload can't be read again")
+
+// maxerr is the most of an error response body retained by Error.
+const maxerr = 1 << 16
+
+func apierr(res *http.Response) error {
+	if res.StatusCode > 299 {
+		defer res.Body.Close()
+		buf := new(bytes.Buffer)
+		io.CopyN(buf, res.Body, maxerr)
+		e := Error{
+			Status:     res.Status,
+			StatusCode: res.StatusCode,
+			Stringer:   buf,
+			Message:    message(buf.Bytes()),
+			Header:     res.Header,
+		}
+		if req := res.Request; req != nil {
+			e.Method = req.Method
+			e.Endpoint = req.URL.String()
+		}
+		return e
+	}
+	return nil
+}
+
+// message returns the "message" field of a JSON
+// body, or the body itself if it isn't JSON.
+func message(body []byte) string {
+	var o struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &o); err == nil {
+		return o.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// Error is an HTTP error from the endpoint. The
+// Stringer is the response body, Message is the
+// "message" field of the response if present.
+type Error struct {
+	Status     string
+	StatusCode int
+	fmt.Stringer
+	Message  string
+	Method   string
+	Endpoint string
+	Header   http.Header
+}
+
+// Err returns *Error if e is or wraps
+// Error, nil otherwise.
+func Err(e error) *Error {
+	var p *Error
+	if errors.As(e, &p) {
+		return p
+	}
+	return nil
+}
+
+// Error implements error.
+func (e Error) Error() string {
+	s := fmt.Sprintf("(%v) %s", e.StatusCode, e.Status)
+	if len(e.Method) > 0 {
+		s = fmt.Sprintf("%s %s: %s", e.Method, e.Endpoint, s)
+	}
+	if len(e.Message) > 0 {
+		s = fmt.Sprintf("%s: %s", s, e.Message)
+	}
+	return s
+}
+
+// Class returns the error class of e, nil if
+// e has none.
+func (e Error) Class() error {
+	switch c := e.StatusCode; {
+	case c == http.StatusBadRequest:
+		return ErrBadRequest
+	case c == http.StatusUnauthorized, c == http.StatusForbidden:
+		return ErrUnauthorized
+	case c == http.StatusNotFound:
+		return ErrNotFound
+	case c == http.StatusRequestEntityTooLarge:
+		return ErrPayloadTooLarge
+	case c == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case c > 499:
+		return ErrServer
+	}
+	return nil
+}
+
+// Is reports whether target is the class of e.
+func (e Error) Is(target error) bool {
+	return target != nil && e.Class() == target
+}
+
+// As sets target if it is a **Error, allowing errors.As
+// with either Error or *Error.
+func (e Error) As(target interface{}) bool {
+	if p, ok := target.(**Error); ok {
+		*p = &e
+		return true
+	}
+	return false
+}
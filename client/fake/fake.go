@@ -0,0 +1,278 @@
+// Package fake implements an in-process fake of the mailgun API.
+/*
+The Server is an httptest.Server that keeps state for messages,
+domains, mailing lists and members, suppressions, tags, templates,
+webhooks and events, so tests can, for example, add a bounce and
+read it back:
+
+	c := fake.Client(t)
+	api := bounce.Bounces(c)
+	if err := api.Add(`a@example.com`, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := api.Get(`a@example.com`)
+
+Sent messages produce accepted events, and delivered or failed
+events depending on the suppression lists of the domain. Unlike
+package mock, the Server needs neither GOPATH nor fixture files.
+*/
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/j7b/mailgun/client"
+)
+
+// Default domain and API key of a Server.
+const (
+	Domain = `domain.fake`
+	Key    = `key-fake`
+)
+
+// Server is a fake mailgun API server.
+type Server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	domains  map[string]*domain
+	lists    map[string]*list
+	messages []Message
+	seq      int
+}
+
+// New returns a started Server with the default domain,
+// closed when t's test ends.
+func New(t testing.TB) *Server {
+	s := &Server{domains: make(map[string]*domain), lists: make(map[string]*list)}
+	s.AddDomain(Domain)
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a Caller for the default domain of a new Server.
+func Client(t testing.TB) client.Caller {
+	return New(t).Caller()
+}
+
+// Endpoint returns the API base URL of s.
+func (s *Server) Endpoint() string {
+	return s.URL + `/v3/`
+}
+
+// Caller returns a Caller for the default domain of s.
+func (s *Server) Caller() *client.Requester {
+	return client.New(s.Endpoint(), Key, Domain)
+}
+
+// AddDomain adds an active domain to s.
+func (s *Server) AddDomain(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adddomain(name, ``, `disabled`, false)
+}
+
+// Messages returns the messages s has accepted, in order.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := make([]Message, len(s.messages))
+	copy(m, s.messages)
+	return m
+}
+
+func (s *Server) id() string {
+	s.seq++
+	return fmt.Sprintf(`%d.%d`, time.Now().UnixNano(), s.seq)
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC1123)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, key, ok := r.BasicAuth(); !ok || user != `api` || key != Key {
+		fail(w, http.StatusUnauthorized, `Invalid private key`)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, `/v3/`) {
+		notfound(w)
+		return
+	}
+	segs := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, `/v3/`), `/`), `/`)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch segs[0] {
+	case `domains`:
+		s.serveDomains(w, r, segs[1:])
+	case `lists`:
+		s.serveLists(w, r, segs[1:])
+	default:
+		d, ok := s.domains[segs[0]]
+		if !ok {
+			fail(w, http.StatusNotFound, `Domain not found`)
+			return
+		}
+		s.serveDomain(w, r, d, segs[1:])
+	}
+}
+
+func reply(w http.ResponseWriter, v interface{}) {
+	w.Header().Set(`Content-Type`, `application/json`)
+	json.NewEncoder(w).Encode(v)
+}
+
+func fail(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(`Content-Type`, `application/json`)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{`message`: message})
+}
+
+func notfound(w http.ResponseWriter) {
+	fail(w, http.StatusNotFound, `Not Found`)
+}
+
+func message(w http.ResponseWriter, message string) {
+	reply(w, map[string]string{`message`: message})
+}
+
+// form parses the urlencoded or multipart form of r.
+func form(r *http.Request) error {
+	if strings.HasPrefix(r.Header.Get(`Content-Type`), `multipart/`) {
+		return multipartform(r)
+	}
+	return r.ParseForm()
+}
+
+// multipartform parses the multipart form of r like
+// ParseMultipartForm, without its limit on the number of
+// parts, which a message with 1000 recipients exceeds.
+func multipartform(r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	values := make(url.Values)
+	buf := new(bytes.Buffer)
+	files := multipart.NewWriter(buf)
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(p.FileName()) == 0 {
+			b, err := io.ReadAll(p)
+			if err != nil {
+				return err
+			}
+			values.Add(p.FormName(), string(b))
+			continue
+		}
+		w, err := files.CreatePart(p.Header)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(w, p); err != nil {
+			return err
+		}
+	}
+	if err = files.Close(); err != nil {
+		return err
+	}
+	f, err := multipart.NewReader(buf, files.Boundary()).ReadForm(32 << 20)
+	if err != nil {
+		return err
+	}
+	f.Value = values
+	for k, v := range values {
+		r.Form[k] = append(r.Form[k], v...)
+		r.PostForm[k] = append(r.PostForm[k], v...)
+	}
+	r.MultipartForm = f
+	return nil
+}
+
+func yes(s string) bool {
+	switch strings.ToLower(s) {
+	case `yes`, `true`:
+		return true
+	}
+	return false
+}
+
+func keys[T any](m map[string]T) []string {
+	k := make([]string, 0, len(m))
+	for key := range m {
+		k = append(k, key)
+	}
+	sort.Strings(k)
+	return k
+}
+
+// paging returns the bounds of the page of n items selected
+// by the skip and limit query parameters, and paging URLs.
+func (s *Server) paging(r *http.Request, n int) (int, int, map[string]string) {
+	q := r.URL.Query()
+	limit, err := strconv.Atoi(q.Get(`limit`))
+	if err != nil || limit < 1 {
+		limit = 100
+	}
+	skip, err := strconv.Atoi(q.Get(`skip`))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+	at := func(skip int) string {
+		if skip < 0 {
+			skip = 0
+		}
+		return fmt.Sprintf(`%s%s?skip=%d&limit=%d`, s.URL, r.URL.Path, skip, limit)
+	}
+	last := n - limit
+	if last < 0 {
+		last = 0
+	}
+	end := skip + limit
+	if end > n {
+		end = n
+	}
+	if skip > n {
+		skip = n
+	}
+	return skip, end, map[string]string{
+		`first`:    at(0),
+		`next`:     at(skip + limit),
+		`previous`: at(skip - limit),
+		`last`:     at(last),
+	}
+}
+
+// page replies with a page of items, selected by the skip
+// and limit query parameters, and paging URLs.
+func (s *Server) page(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	skip, end, paging := s.paging(r, len(items))
+	reply(w, map[string]interface{}{
+		`items`:       append([]interface{}{}, items[skip:end]...),
+		`total_count`: len(items),
+		`paging`:      paging,
+	})
+}
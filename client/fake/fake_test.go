@@ -0,0 +1,181 @@
+package fake_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/fake"
+	"github.com/j7b/mailgun/domain"
+	"github.com/j7b/mailgun/event"
+	"github.com/j7b/mailgun/event/types"
+	"github.com/j7b/mailgun/list"
+	"github.com/j7b/mailgun/list/member"
+	"github.com/j7b/mailgun/message"
+	"github.com/j7b/mailgun/suppression/bounce"
+	"github.com/j7b/mailgun/tags"
+	"github.com/j7b/mailgun/webhook"
+)
+
+func TestBounces(t *testing.T) {
+	api := bounce.Bounces(fake.Client(t))
+	code, e := 554, `mailbox full`
+	if err := api.Add(`a@example.com`, &code, &e, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := api.Get(`a@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Code != `554` || b.Error != e {
+		t.Fatal(`unexpected bounce`, b)
+	}
+	if err = api.Delete(`a@example.com`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = api.Get(`a@example.com`); !errors.Is(err, client.ErrNotFound) {
+		t.Fatal(`want`, client.ErrNotFound, `got`, err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	s := fake.New(t)
+	c := s.Caller()
+	if err := bounce.Bounces(c).AddList([]bounce.Bounce{{Address: `b@example.com`}}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := message.New(`sender@domain.fake`, `Hello`, `<b>hi</b>`, `a@example.com`, `b@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Tag = `welcome`
+	res, err := msg.Send(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := s.Messages()
+	if len(sent) != 1 || sent[0].ID != res.ID || sent[0].Subject != `Hello` {
+		t.Fatal(`unexpected messages`, sent)
+	}
+	asc := true
+	events, err := event.Queries(c).Query(nil, nil, &asc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var delivered, failed int
+	for e, err := range events.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch e := e.(type) {
+		case types.Delivered:
+			delivered++
+			if e.Recipient != `a@example.com` {
+				t.Fatal(`unexpected delivery to`, e.Recipient)
+			}
+		case types.Failed:
+			failed++
+		}
+	}
+	if delivered != 1 || failed != 1 {
+		t.Fatal(`want 1 delivered and 1 failed got`, delivered, failed)
+	}
+	tag, err := tags.Get(c, `welcome`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Tag != `welcome` {
+		t.Fatal(`unexpected tag`, tag)
+	}
+}
+
+func TestLists(t *testing.T) {
+	c := fake.Client(t)
+	if err := list.New(c, `dev@domain.fake`, `Dev`, ``, list.AccessMembers); err != nil {
+		t.Fatal(err)
+	}
+	m := list.Manager(c, `dev@domain.fake`)
+	l := member.NewList()
+	for _, a := range []string{`a@example.com`, `b@example.com`, `c@example.com`} {
+		l.Add(a, ``, nil)
+	}
+	if err := m.Add(l); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Delete(`b@example.com`); err != nil {
+		t.Fatal(err)
+	}
+	dev, err := list.Address(c, `dev@domain.fake`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.Members != 2 || dev.AccessLevel != `members` {
+		t.Fatal(`unexpected list`, dev)
+	}
+	page, err := m.Members()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for mbr, err := range page.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, mbr.Address)
+	}
+	if len(got) != 2 || got[0] != `a@example.com` || got[1] != `c@example.com` {
+		t.Fatal(`unexpected members`, got)
+	}
+}
+
+func TestDomainsAndWebhooks(t *testing.T) {
+	c := fake.Client(t)
+	api := domain.API(c)
+	if _, err := api.New(`other.fake`, `secret`, domain.ActionTag, false); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := api.List(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 2 {
+		t.Fatal(`want 2 domains got`, len(ds))
+	}
+	if err = webhook.New(c, webhook.Click, `https://example.com/click`); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := webhook.Get(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks.Click.URL != `https://example.com/click` {
+		t.Fatal(`unexpected webhooks`, hooks)
+	}
+}
+
+func TestBadPaths(t *testing.T) {
+	s := fake.New(t)
+	for _, c := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodPut, `/v3/domains/` + fake.Domain, http.StatusMethodNotAllowed},
+		{http.MethodGet, `/v3/` + fake.Domain, http.StatusNotFound},
+		{http.MethodGet, `/v3/` + fake.Domain + `/`, http.StatusNotFound},
+	} {
+		req, err := http.NewRequest(c.method, s.URL+c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth(`api`, fake.Key)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(c.method, c.path, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != c.code {
+			t.Error(c.method, c.path, `want`, c.code, `got`, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,767 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type domain struct {
+	info         domaininfo
+	webhooks     map[string]string
+	tags         map[string]*tag
+	templates    map[string]*template
+	suppressions map[string]map[string]*suppression
+	events       []map[string]interface{}
+}
+
+type domaininfo struct {
+	CreatedAt    string `json:"created_at"`
+	SMTPLogin    string `json:"smtp_login"`
+	Name         string `json:"name"`
+	SMTPPassword string `json:"smtp_password"`
+	Wildcard     bool   `json:"wildcard"`
+	SpamAction   string `json:"spam_action"`
+	State        string `json:"state"`
+}
+
+type tag struct {
+	Tag         string `json:"tag"`
+	Description string `json:"description"`
+}
+
+type suppression struct {
+	Address   string `json:"address"`
+	Code      string `json:"code,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type list struct {
+	AccessLevel string `json:"access_level"`
+	Address     string `json:"address"`
+	CreatedAt   string `json:"created_at"`
+	Description string `json:"description"`
+	Members     int    `json:"members_count"`
+	Name        string `json:"name"`
+	members     map[string]*member
+}
+
+type member struct {
+	Address    string                 `json:"address"`
+	Name       string                 `json:"name"`
+	Subscribed bool                   `json:"subscribed"`
+	Vars       map[string]interface{} `json:"vars"`
+}
+
+// File is a file part of a Message.
+type File struct {
+	Field string // "attachment" or "inline"
+	Name  string
+	Data  []byte
+}
+
+// Message is a message accepted by a Server.
+type Message struct {
+	ID      string
+	Domain  string
+	From    string
+	To      []string
+	CC      []string
+	BCC     []string
+	Subject string
+	Text    string
+	HTML    string
+	Tags    []string
+	Form    url.Values // every field as sent
+	Files   []File
+	MIME    []byte // sent to messages.mime
+}
+
+// mime sets m from the message part of a messages.mime request.
+func (m *Message) mime(r *http.Request) error {
+	if r.MultipartForm == nil || len(r.MultipartForm.File[`message`]) == 0 {
+		return fmt.Errorf(`'message' parameter is missing`)
+	}
+	f, err := r.MultipartForm.File[`message`][0].Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if m.MIME, err = io.ReadAll(f); err != nil {
+		return err
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(m.MIME))
+	if err != nil {
+		return err
+	}
+	m.From = msg.Header.Get(`From`)
+	m.Subject = msg.Header.Get(`Subject`)
+	return nil
+}
+
+// Recipients returns the to, cc and bcc recipients of m.
+func (m Message) Recipients() []string {
+	r := append([]string{}, m.To...)
+	r = append(r, m.CC...)
+	return append(r, m.BCC...)
+}
+
+// suppression kinds
+const (
+	bounces      = `bounces`
+	complaints   = `complaints`
+	unsubscribes = `unsubscribes`
+)
+
+func (s *Server) adddomain(name, password, action string, wildcard bool) *domain {
+	d := &domain{
+		info: domaininfo{
+			CreatedAt:    now(),
+			SMTPLogin:    `postmaster@` + name,
+			Name:         name,
+			SMTPPassword: password,
+			Wildcard:     wildcard,
+			SpamAction:   action,
+			State:        `active`,
+		},
+		webhooks:  make(map[string]string),
+		tags:      make(map[string]*tag),
+		templates: make(map[string]*template),
+		suppressions: map[string]map[string]*suppression{
+			bounces:      make(map[string]*suppression),
+			complaints:   make(map[string]*suppression),
+			unsubscribes: make(map[string]*suppression),
+		},
+	}
+	s.domains[name] = d
+	return d
+}
+
+func info(d *domain) map[string]interface{} {
+	return map[string]interface{}{
+		`domain`:                d.info,
+		`receiving_dns_records`: []interface{}{},
+		`sending_dns_records`:   []interface{}{},
+	}
+}
+
+func (s *Server) serveDomains(w http.ResponseWriter, r *http.Request, segs []string) {
+	if len(segs) == 1 && len(segs[0]) == 0 {
+		segs = nil
+	}
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, k := range keys(s.domains) {
+			items = append(items, s.domains[k].info)
+		}
+		s.page(w, r, items)
+	case len(segs) == 0 && r.Method == http.MethodPost:
+		if err := form(r); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		name := r.FormValue(`name`)
+		if len(name) == 0 {
+			fail(w, http.StatusBadRequest, `name is required`)
+			return
+		}
+		if _, ok := s.domains[name]; ok {
+			fail(w, http.StatusBadRequest, `Domain already exists`)
+			return
+		}
+		action := r.FormValue(`spam_action`)
+		if len(action) == 0 {
+			action = r.FormValue(`action`)
+		}
+		d := s.adddomain(name, r.FormValue(`password`), action, yes(r.FormValue(`wildcard`)))
+		o := info(d)
+		o[`message`] = `Domain has been created`
+		reply(w, o)
+	case len(segs) == 0:
+		fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+	default:
+		d, ok := s.domains[segs[0]]
+		if !ok {
+			fail(w, http.StatusNotFound, `Domain not found`)
+			return
+		}
+		switch {
+		case len(segs) == 1 && r.Method == http.MethodGet:
+			reply(w, info(d))
+		case len(segs) == 1 && r.Method == http.MethodDelete:
+			delete(s.domains, segs[0])
+			message(w, `Domain has been deleted`)
+		case len(segs) == 1:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		case segs[1] == `webhooks`:
+			s.serveWebhooks(w, r, d, segs[2:])
+		default:
+			notfound(w)
+		}
+	}
+}
+
+func (s *Server) serveWebhooks(w http.ResponseWriter, r *http.Request, d *domain, segs []string) {
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet:
+		o := make(map[string]interface{})
+		for id, u := range d.webhooks {
+			o[id] = map[string]string{`url`: u}
+		}
+		reply(w, map[string]interface{}{`webhooks`: o})
+	case len(segs) == 0 && r.Method == http.MethodPost:
+		if err := form(r); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		id, u := r.FormValue(`id`), r.FormValue(`url`)
+		if len(id) == 0 || len(u) == 0 {
+			fail(w, http.StatusBadRequest, `id and url are required`)
+			return
+		}
+		if _, ok := d.webhooks[id]; ok {
+			fail(w, http.StatusBadRequest, `Webhook already exists`)
+			return
+		}
+		d.webhooks[id] = u
+		reply(w, map[string]interface{}{`message`: `Webhook has been created`, `webhook`: map[string]string{`url`: u}})
+	case len(segs) == 1:
+		u, ok := d.webhooks[segs[0]]
+		if !ok {
+			fail(w, http.StatusNotFound, `Webhook not found`)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			if err := form(r); err != nil {
+				fail(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			u = r.FormValue(`url`)
+			d.webhooks[segs[0]] = u
+		case http.MethodDelete:
+			delete(d.webhooks, segs[0])
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+			return
+		}
+		reply(w, map[string]interface{}{`webhook`: map[string]string{`url`: u}})
+	default:
+		notfound(w)
+	}
+}
+
+func (s *Server) serveDomain(w http.ResponseWriter, r *http.Request, d *domain, segs []string) {
+	if len(segs) == 0 {
+		notfound(w)
+		return
+	}
+	switch segs[0] {
+	case `messages`, `messages.mime`:
+		if len(segs) > 1 || r.Method != http.MethodPost {
+			notfound(w)
+			return
+		}
+		s.serveMessages(w, r, d, segs[0] == `messages.mime`)
+	case `events`:
+		s.serveEvents(w, r, d)
+	case bounces, complaints, unsubscribes:
+		s.serveSuppressions(w, r, d, segs[0], segs[1:])
+	case `tags`:
+		s.serveTags(w, r, d, segs[1:])
+	case `templates`:
+		s.serveTemplates(w, r, d, segs[1:])
+	default:
+		notfound(w)
+	}
+}
+
+func (s *Server) serveMessages(w http.ResponseWriter, r *http.Request, d *domain, mime bool) {
+	if err := form(r); err != nil {
+		fail(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f := r.Form
+	if r.MultipartForm != nil {
+		f = r.MultipartForm.Value
+	}
+	m := Message{
+		ID:      fmt.Sprintf(`<%s@%s>`, s.id(), d.info.Name),
+		Domain:  d.info.Name,
+		From:    f.Get(`from`),
+		To:      f[`to`],
+		CC:      f[`cc`],
+		BCC:     f[`bcc`],
+		Subject: f.Get(`subject`),
+		Text:    f.Get(`text`),
+		HTML:    f.Get(`html`),
+		Tags:    f[`o:tag`],
+		Form:    f,
+	}
+	if mime {
+		if err := m.mime(r); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	switch {
+	case len(m.From) == 0:
+		fail(w, http.StatusBadRequest, `'from' parameter is missing`)
+		return
+	case len(m.To) == 0:
+		fail(w, http.StatusBadRequest, `'to' parameter is missing`)
+		return
+	}
+	if r.MultipartForm != nil {
+		for _, field := range []string{`attachment`, `inline`} {
+			for _, fh := range r.MultipartForm.File[field] {
+				rd, err := fh.Open()
+				if err != nil {
+					fail(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				data, err := io.ReadAll(rd)
+				rd.Close()
+				if err != nil {
+					fail(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				m.Files = append(m.Files, File{Field: field, Name: fh.Filename, Data: data})
+			}
+		}
+	}
+	s.messages = append(s.messages, m)
+	for _, t := range m.Tags {
+		if _, ok := d.tags[t]; !ok {
+			d.tags[t] = &tag{Tag: t}
+		}
+	}
+	s.deliver(d, m)
+	reply(w, map[string]string{`id`: m.ID, `message`: `Queued. Thank you.`})
+}
+
+// deliver records the events of sending m.
+func (s *Server) deliver(d *domain, m Message) {
+	testmode := yes(m.Form.Get(`o:testmode`))
+	vars := make(map[string]interface{})
+	for k, v := range m.Form {
+		if strings.HasPrefix(k, `v:`) {
+			vars[k[2:]] = v[0]
+		}
+	}
+	for _, rcpt := range m.Recipients() {
+		event := func(name string) map[string]interface{} {
+			e := map[string]interface{}{
+				`event`:          name,
+				`id`:             s.id(),
+				`timestamp`:      float64(time.Now().UnixNano()) / 1e9,
+				`tags`:           append([]string{}, m.Tags...),
+				`envelope`:       map[string]interface{}{`sender`: m.From, `transport`: `smtp`},
+				`campaigns`:      []interface{}{},
+				`user-variables`: vars,
+				`flags`:          map[string]interface{}{`is-test-mode`: testmode},
+				`recipient`:      rcpt,
+				`message`: map[string]interface{}{
+					`headers`: map[string]string{
+						`to`:         strings.Join(m.To, `, `),
+						`message-id`: strings.Trim(m.ID, `<>`),
+						`from`:       m.From,
+						`subject`:    m.Subject,
+					},
+					`attachments`: []interface{}{},
+					`recipients`:  m.Recipients(),
+					`size`:        len(m.Text) + len(m.HTML),
+				},
+			}
+			d.events = append(d.events, e)
+			return e
+		}
+		event(`accepted`)[`method`] = `http`
+		if testmode {
+			continue
+		}
+		reason := ``
+		for _, kind := range []string{bounces, unsubscribes, complaints} {
+			if _, ok := d.suppressions[kind][rcpt]; ok {
+				reason = `suppress-` + strings.TrimSuffix(kind, `s`)
+				break
+			}
+		}
+		if len(reason) > 0 {
+			e := event(`failed`)
+			e[`severity`] = `permanent`
+			e[`reason`] = reason
+			e[`delivery-status`] = map[string]interface{}{`code`: 605, `message`: `Not delivering to suppressed address`}
+			continue
+		}
+		event(`delivered`)[`delivery-status`] = map[string]interface{}{`code`: 250, `message`: `OK`}
+	}
+}
+
+func has(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request, d *domain) {
+	if r.Method != http.MethodGet {
+		fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		return
+	}
+	q := r.URL.Query()
+	var names []string
+	for _, e := range q[`event`] {
+		names = append(names, strings.Fields(strings.ReplaceAll(e, ` OR `, ` `))...)
+	}
+	var items []interface{}
+	for _, e := range d.events {
+		if !has(names, e[`event`].(string)) {
+			continue
+		}
+		if rcpt := q.Get(`recipient`); len(rcpt) > 0 && e[`recipient`] != rcpt {
+			continue
+		}
+		if t := q.Get(`tags`); len(t) > 0 && !has(e[`tags`].([]string), t) {
+			continue
+		}
+		items = append(items, e)
+	}
+	if !yes(q.Get(`ascending`)) {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	s.page(w, r, items)
+}
+
+func (s *Server) serveSuppressions(w http.ResponseWriter, r *http.Request, d *domain, kind string, segs []string) {
+	set := d.suppressions[kind]
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, k := range keys(set) {
+			items = append(items, set[k])
+		}
+		s.page(w, r, items)
+	case len(segs) == 0 && r.Method == http.MethodPost:
+		var list []*suppression
+		if strings.HasPrefix(r.Header.Get(`Content-Type`), `application/json`) {
+			if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+				fail(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		} else {
+			if err := form(r); err != nil {
+				fail(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			list = append(list, &suppression{
+				Address:   r.FormValue(`address`),
+				Code:      r.FormValue(`code`),
+				Error:     r.FormValue(`error`),
+				Tag:       r.FormValue(`tag`),
+				CreatedAt: r.FormValue(`created_at`),
+			})
+		}
+		for _, sp := range list {
+			if len(sp.Address) == 0 {
+				fail(w, http.StatusBadRequest, `address is required`)
+				return
+			}
+		}
+		for _, sp := range list {
+			if len(sp.CreatedAt) == 0 {
+				sp.CreatedAt = now()
+			}
+			if kind == bounces && len(sp.Code) == 0 {
+				sp.Code = `550`
+			}
+			if kind == unsubscribes && len(sp.Tag) == 0 {
+				sp.Tag = `*`
+			}
+			set[sp.Address] = sp
+		}
+		message(w, fmt.Sprintf(`%d addresses have been added to the %s table`, len(list), kind))
+	case len(segs) == 0 && r.Method == http.MethodDelete:
+		for k := range set {
+			delete(set, k)
+		}
+		message(w, fmt.Sprintf(`%s have been removed`, kind))
+	case len(segs) == 1:
+		sp, ok := set[segs[0]]
+		if !ok {
+			fail(w, http.StatusNotFound, `Address not found in `+kind+` table`)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			reply(w, sp)
+		case http.MethodDelete:
+			delete(set, segs[0])
+			reply(w, map[string]string{`address`: sp.Address, `message`: `Address has been removed`})
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		}
+	default:
+		notfound(w)
+	}
+}
+
+func (s *Server) serveTags(w http.ResponseWriter, r *http.Request, d *domain, segs []string) {
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, k := range keys(d.tags) {
+			items = append(items, d.tags[k])
+		}
+		s.page(w, r, items)
+	case len(segs) == 1:
+		t, ok := d.tags[segs[0]]
+		if !ok {
+			fail(w, http.StatusNotFound, `Tag not found`)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			reply(w, t)
+		case http.MethodPut:
+			if err := form(r); err != nil {
+				fail(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			t.Description = r.FormValue(`description`)
+			message(w, `Tag updated`)
+		case http.MethodDelete:
+			delete(d.tags, segs[0])
+			message(w, `Tag deleted`)
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		}
+	default:
+		notfound(w)
+	}
+}
+
+func (s *Server) serveLists(w http.ResponseWriter, r *http.Request, segs []string) {
+	if len(segs) == 1 && len(segs[0]) == 0 {
+		segs = nil
+	}
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodPost:
+		if err := form(r); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		addr := r.FormValue(`address`)
+		if len(addr) == 0 {
+			fail(w, http.StatusBadRequest, `address is required`)
+			return
+		}
+		if _, ok := s.lists[addr]; ok {
+			fail(w, http.StatusBadRequest, `Duplicate object`)
+			return
+		}
+		l := &list{
+			AccessLevel: r.FormValue(`access_level`),
+			Address:     addr,
+			CreatedAt:   now(),
+			Description: r.FormValue(`description`),
+			Name:        r.FormValue(`name`),
+			members:     make(map[string]*member),
+		}
+		if len(l.AccessLevel) == 0 {
+			l.AccessLevel = `readonly`
+		}
+		s.lists[addr] = l
+		reply(w, map[string]interface{}{`list`: l, `message`: `Mailing list has been created`})
+	case len(segs) == 1 && segs[0] == `pages` && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, k := range keys(s.lists) {
+			items = append(items, s.lists[k])
+		}
+		s.page(w, r, items)
+	case len(segs) == 0:
+		notfound(w)
+	default:
+		l, ok := s.lists[segs[0]]
+		if !ok {
+			fail(w, http.StatusNotFound, `Mailing list `+segs[0]+` not found`)
+			return
+		}
+		if len(segs) == 1 {
+			s.serveList(w, r, l)
+			return
+		}
+		s.serveMembers(w, r, l, segs[1:])
+	}
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, l *list) {
+	switch r.Method {
+	case http.MethodGet:
+		reply(w, map[string]interface{}{`list`: l})
+	case http.MethodPut:
+		if err := form(r); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if v := r.FormValue(`address`); len(v) > 0 && v != l.Address {
+			delete(s.lists, l.Address)
+			l.Address = v
+			s.lists[v] = l
+		}
+		if v, ok := r.Form[`name`]; ok {
+			l.Name = v[0]
+		}
+		if v, ok := r.Form[`description`]; ok {
+			l.Description = v[0]
+		}
+		if v := r.FormValue(`access_level`); len(v) > 0 {
+			l.AccessLevel = v
+		}
+		reply(w, map[string]interface{}{`list`: l, `message`: `Mailing list has been updated`})
+	case http.MethodDelete:
+		delete(s.lists, l.Address)
+		reply(w, map[string]string{`address`: l.Address, `message`: `Mailing list has been removed`})
+	default:
+		fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+	}
+}
+
+// upsert adds or updates m in l, keeping the subscription status
+// of existing members unless subscribed is not nil.
+func (l *list) upsert(m *member, subscribed *bool) {
+	if old, ok := l.members[m.Address]; ok && subscribed == nil {
+		m.Subscribed = old.Subscribed
+	} else if subscribed != nil {
+		m.Subscribed = *subscribed
+	} else {
+		m.Subscribed = true
+	}
+	l.members[m.Address] = m
+	l.Members = len(l.members)
+}
+
+func vars(s string) (map[string]interface{}, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var v map[string]interface{}
+	return v, json.Unmarshal([]byte(s), &v)
+}
+
+func (s *Server) serveMembers(w http.ResponseWriter, r *http.Request, l *list, segs []string) {
+	switch {
+	case len(segs) == 2 && segs[0] == `members` && segs[1] == `pages` && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, k := range keys(l.members) {
+			items = append(items, l.members[k])
+		}
+		s.page(w, r, items)
+	case len(segs) == 1 && segs[0] == `members` && r.Method == http.MethodPost:
+		if err := form(r); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		addr := r.FormValue(`address`)
+		if len(addr) == 0 {
+			fail(w, http.StatusBadRequest, `address is required`)
+			return
+		}
+		if _, ok := l.members[addr]; ok && !yes(r.FormValue(`upsert`)) {
+			fail(w, http.StatusBadRequest, `Address already exists '`+addr+`'`)
+			return
+		}
+		v, err := vars(r.FormValue(`vars`))
+		if err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		m := &member{Address: addr, Name: r.FormValue(`name`), Vars: v}
+		var sub *bool
+		if v := r.FormValue(`subscribed`); len(v) > 0 {
+			b := yes(v)
+			sub = &b
+		}
+		l.upsert(m, sub)
+		reply(w, map[string]interface{}{`member`: m, `message`: `Mailing list member has been created`})
+	case len(segs) == 1 && segs[0] == `members.json` && r.Method == http.MethodPost:
+		if err := form(r); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var mems []*member
+		if err := json.Unmarshal([]byte(r.FormValue(`members`)), &mems); err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		upsert := yes(r.FormValue(`upsert`))
+		for _, m := range mems {
+			if _, ok := l.members[m.Address]; ok && !upsert {
+				continue
+			}
+			l.upsert(m, nil)
+		}
+		reply(w, map[string]interface{}{`list`: l, `message`: `Mailing list has been updated`})
+	case len(segs) == 2 && segs[0] == `members`:
+		m, ok := l.members[segs[1]]
+		if !ok {
+			fail(w, http.StatusNotFound, `Member `+segs[1]+` of mailing list `+l.Address+` not found`)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			reply(w, map[string]interface{}{`member`: m})
+		case http.MethodPut:
+			if err := form(r); err != nil {
+				fail(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if v := r.FormValue(`address`); len(v) > 0 && v != m.Address {
+				delete(l.members, m.Address)
+				m.Address = v
+				l.members[v] = m
+			}
+			if v, ok := r.Form[`name`]; ok {
+				m.Name = v[0]
+			}
+			if v := r.FormValue(`vars`); len(v) > 0 {
+				vs, err := vars(v)
+				if err != nil {
+					fail(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				m.Vars = vs
+			}
+			if v := r.FormValue(`subscribed`); len(v) > 0 {
+				m.Subscribed = yes(v)
+			}
+			reply(w, map[string]interface{}{`member`: m, `message`: `Mailing list member has been updated`})
+		case http.MethodDelete:
+			delete(l.members, m.Address)
+			l.Members = len(l.members)
+			reply(w, map[string]interface{}{`member`: map[string]string{`address`: m.Address}, `message`: `Mailing list member has been deleted`})
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		}
+	default:
+		notfound(w)
+	}
+}
@@ -0,0 +1,211 @@
+package fake
+
+import (
+	"net/http"
+)
+
+type template struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"createdAt"`
+	CreatedBy   string `json:"createdBy"`
+	ID          string `json:"id"`
+	versions    []*version
+}
+
+type version struct {
+	Tag       string `json:"tag"`
+	Template  string `json:"template"`
+	Engine    string `json:"engine"`
+	Comment   string `json:"comment"`
+	Active    bool   `json:"active"`
+	CreatedAt string `json:"createdAt"`
+	ID        string `json:"id"`
+}
+
+// with returns t as JSON with the field k set to v.
+func (t *template) with(k string, v interface{}) map[string]interface{} {
+	m := map[string]interface{}{
+		`name`:        t.Name,
+		`description`: t.Description,
+		`createdAt`:   t.CreatedAt,
+		`createdBy`:   t.CreatedBy,
+		`id`:          t.ID,
+	}
+	if len(k) > 0 {
+		m[k] = v
+	}
+	return m
+}
+
+func (t *template) version(tag string) *version {
+	for _, v := range t.versions {
+		if v.Tag == tag {
+			return v
+		}
+	}
+	return nil
+}
+
+func (t *template) active() *version {
+	for _, v := range t.versions {
+		if v.Active {
+			return v
+		}
+	}
+	return nil
+}
+
+func (t *template) activate(a *version) {
+	for _, v := range t.versions {
+		v.Active = v == a
+	}
+}
+
+// addversion adds the version in the form of r to t.
+func (s *Server) addversion(w http.ResponseWriter, r *http.Request, t *template) *version {
+	v := &version{
+		Tag:       r.FormValue(`tag`),
+		Template:  r.FormValue(`template`),
+		Engine:    r.FormValue(`engine`),
+		Comment:   r.FormValue(`comment`),
+		CreatedAt: now(),
+		ID:        s.id(),
+	}
+	switch {
+	case len(v.Template) == 0:
+		fail(w, http.StatusBadRequest, `template is required`)
+		return nil
+	case len(v.Tag) == 0:
+		v.Tag = `initial`
+	}
+	if t.version(v.Tag) != nil {
+		fail(w, http.StatusConflict, `version tag already exists`)
+		return nil
+	}
+	if len(v.Engine) == 0 {
+		v.Engine = `handlebars`
+	}
+	t.versions = append(t.versions, v)
+	if len(t.versions) == 1 || yes(r.FormValue(`active`)) {
+		t.activate(v)
+	}
+	return v
+}
+
+func (s *Server) serveTemplates(w http.ResponseWriter, r *http.Request, d *domain, segs []string) {
+	if err := form(r); err != nil {
+		fail(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(segs) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			var items []interface{}
+			for _, k := range keys(d.templates) {
+				items = append(items, d.templates[k].with(``, nil))
+			}
+			s.page(w, r, items)
+		case http.MethodPost:
+			name := r.FormValue(`name`)
+			if len(name) == 0 {
+				fail(w, http.StatusBadRequest, `name is required`)
+				return
+			}
+			if _, ok := d.templates[name]; ok {
+				fail(w, http.StatusConflict, `template already exists`)
+				return
+			}
+			t := &template{Name: name, Description: r.FormValue(`description`), CreatedAt: now(), CreatedBy: r.FormValue(`createdBy`), ID: s.id()}
+			var v *version
+			if len(r.FormValue(`template`)) > 0 {
+				if v = s.addversion(w, r, t); v == nil {
+					return
+				}
+			}
+			d.templates[name] = t
+			res := t.with(``, nil)
+			if v != nil {
+				res = t.with(`version`, v)
+			}
+			reply(w, map[string]interface{}{`message`: `template has been stored`, `template`: res})
+		case http.MethodDelete:
+			d.templates = make(map[string]*template)
+			message(w, `templates have been deleted`)
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		}
+		return
+	}
+	t, ok := d.templates[segs[0]]
+	if !ok {
+		fail(w, http.StatusNotFound, `template not found`)
+		return
+	}
+	switch {
+	case len(segs) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			res := t.with(``, nil)
+			if yes(r.URL.Query().Get(`active`)) {
+				res = t.with(`version`, t.active())
+			}
+			reply(w, map[string]interface{}{`template`: res})
+		case http.MethodPut:
+			t.Description = r.FormValue(`description`)
+			reply(w, map[string]interface{}{`message`: `template has been updated`, `template`: map[string]string{`name`: t.Name}})
+		case http.MethodDelete:
+			delete(d.templates, t.Name)
+			reply(w, map[string]interface{}{`message`: `template has been deleted`, `template`: map[string]string{`name`: t.Name}})
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		}
+	case segs[1] != `versions`:
+		notfound(w)
+	case len(segs) == 2:
+		switch r.Method {
+		case http.MethodGet:
+			skip, end, paging := s.paging(r, len(t.versions))
+			reply(w, map[string]interface{}{`template`: t.with(`versions`, t.versions[skip:end]), `paging`: paging})
+		case http.MethodPost:
+			if v := s.addversion(w, r, t); v != nil {
+				reply(w, map[string]interface{}{`message`: `new version of the template has been stored`, `template`: t.with(`version`, v)})
+			}
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		}
+	case len(segs) == 3:
+		v := t.version(segs[2])
+		if v == nil {
+			fail(w, http.StatusNotFound, `version not found`)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			reply(w, map[string]interface{}{`template`: t.with(`version`, v)})
+		case http.MethodPut:
+			if tmpl := r.FormValue(`template`); len(tmpl) > 0 {
+				v.Template = tmpl
+			}
+			if c := r.FormValue(`comment`); len(c) > 0 {
+				v.Comment = c
+			}
+			if yes(r.FormValue(`active`)) {
+				t.activate(v)
+			}
+			reply(w, map[string]interface{}{`message`: `version has been updated`, `template`: map[string]interface{}{`name`: t.Name, `version`: map[string]string{`tag`: v.Tag}}})
+		case http.MethodDelete:
+			for i, tv := range t.versions {
+				if tv == v {
+					t.versions = append(t.versions[:i], t.versions[i+1:]...)
+					break
+				}
+			}
+			reply(w, map[string]interface{}{`message`: `version has been deleted`, `template`: map[string]interface{}{`name`: t.Name, `version`: map[string]string{`tag`: v.Tag}}})
+		default:
+			fail(w, http.StatusMethodNotAllowed, `Method Not Allowed`)
+		}
+	default:
+		notfound(w)
+	}
+}
@@ -0,0 +1,270 @@
+// Package fault implements fault injection for test transports.
+/*
+A Transport wraps another http.RoundTripper, such as that of a
+fake.Server Caller, and injects faults into requests matching
+its Rules: latency, error statuses such as 429 with Retry-After
+or 5xx, truncated bodies, malformed JSON and connection resets.
+A Rule applies on the Nth matching call, or at random with a
+probability, or else to every matching call.
+
+	c := fake.New(t).Caller()
+	fault.Inject(c, fault.Rule{Path: `/messages`, Nth: 1, Fault: fault.TooManyRequests(time.Second)})
+*/
+package fault
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/j7b/mailgun/client"
+)
+
+// Fault performs req with next, misbehaving.
+type Fault func(next http.RoundTripper, req *http.Request) (*http.Response, error)
+
+func respond(req *http.Request, code int, h http.Header, body string) *http.Response {
+	if h == nil {
+		h = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf(`%d %s`, code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         `HTTP/1.1`,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func drain(req *http.Request) {
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, req.Body)
+		req.Body.Close()
+	}
+}
+
+// Delay waits d, or until the request context is done,
+// before performing the request.
+func Delay(d time.Duration) Fault {
+	return func(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-req.Context().Done():
+			drain(req)
+			return nil, req.Context().Err()
+		case <-t.C:
+		}
+		return next.RoundTrip(req)
+	}
+}
+
+// Status responds with code and a JSON message without
+// performing the request.
+func Status(code int) Fault {
+	return func(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+		drain(req)
+		h := http.Header{`Content-Type`: {`application/json`}}
+		return respond(req, code, h, fmt.Sprintf(`{"message": %q}`, http.StatusText(code))), nil
+	}
+}
+
+// TooManyRequests responds 429 with a Retry-After header
+// of after, rounded up to whole seconds.
+func TooManyRequests(after time.Duration) Fault {
+	status := Status(http.StatusTooManyRequests)
+	return func(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+		res, err := status(next, req)
+		secs := (after + time.Second - 1) / time.Second
+		res.Header.Set(`Retry-After`, strconv.Itoa(int(secs)))
+		return res, err
+	}
+}
+
+// ServerError responds with the 5xx code.
+func ServerError(code int) Fault {
+	return Status(code)
+}
+
+type truncated struct {
+	io.Reader
+	io.Closer
+}
+
+func (t truncated) Read(p []byte) (int, error) {
+	n, err := t.Reader.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Truncate performs the request, ending the response
+// body with io.ErrUnexpectedEOF after n bytes.
+func Truncate(n int64) Fault {
+	return func(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		res.Body = truncated{Reader: io.LimitReader(res.Body, n), Closer: res.Body}
+		res.ContentLength = -1
+		return res, nil
+	}
+}
+
+// MalformedJSON performs the request, replacing the
+// response body with the first half of it.
+func MalformedJSON() Fault {
+	return func(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		b = b[:len(b)/2]
+		res.Body = ioutil.NopCloser(bytes.NewReader(b))
+		res.ContentLength = int64(len(b))
+		res.Header.Del(`Content-Length`)
+		return res, nil
+	}
+}
+
+// Reset fails as if the connection were reset by the peer.
+func Reset() Fault {
+	return func(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+		drain(req)
+		return nil, &net.OpError{Op: `read`, Net: `tcp`, Err: os.NewSyscallError(`read`, syscall.ECONNRESET)}
+	}
+}
+
+// Rule injects Fault into requests with Method, if not
+// zero-length, and a path containing Path. If Nth > 0 the
+// Fault is injected on the Nth matching request only,
+// otherwise if Probability > 0 at random, otherwise on
+// every matching request.
+type Rule struct {
+	Method      string
+	Path        string
+	Nth         int
+	Probability float64
+	Fault       Fault
+	calls       int
+}
+
+func (r *Rule) match(req *http.Request) bool {
+	if len(r.Method) > 0 && r.Method != req.Method {
+		return false
+	}
+	return strings.Contains(req.URL.Path, r.Path)
+}
+
+// Transport is an http.RoundTripper injecting faults.
+type Transport struct {
+	Base  http.RoundTripper
+	mu    sync.Mutex
+	rules []*Rule
+	rand  *rand.Rand
+}
+
+var _ = http.RoundTripper(&Transport{})
+
+// New returns a Transport injecting faults into requests
+// performed with base, http.DefaultTransport if nil.
+func New(base http.RoundTripper, rules ...Rule) *Transport {
+	t := &Transport{Base: base, rand: rand.New(rand.NewSource(1))}
+	t.Add(rules...)
+	return t
+}
+
+// Inject wraps the transport of r with a new Transport.
+func Inject(r *client.Requester, rules ...Rule) *Transport {
+	hc := *r.HTTPClient()
+	t := New(hc.Transport, rules...)
+	hc.Transport = t
+	r.Client = &hc
+	return t
+}
+
+// Add adds rules to t.
+func (t *Transport) Add(rules ...Rule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range rules {
+		r := rules[i]
+		t.rules = append(t.rules, &r)
+	}
+}
+
+// Seed seeds the source of randomness for Probability.
+func (t *Transport) Seed(seed int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rand = rand.New(rand.NewSource(seed))
+}
+
+// Calls returns the number of requests matching rule i,
+// in the order rules were added.
+func (t *Transport) Calls(i int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rules[i].calls
+}
+
+func (t *Transport) fault(req *http.Request) Fault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var f Fault
+	for _, r := range t.rules {
+		if !r.match(req) {
+			continue
+		}
+		r.calls++
+		if f != nil || r.Fault == nil {
+			continue
+		}
+		switch {
+		case r.Nth > 0:
+			if r.calls == r.Nth {
+				f = r.Fault
+			}
+		case r.Probability > 0:
+			if t.rand.Float64() < r.Probability {
+				f = r.Fault
+			}
+		default:
+			f = r.Fault
+		}
+	}
+	return f
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if f := t.fault(req); f != nil {
+		return f(base, req)
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,89 @@
+package fault_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/fake"
+	"github.com/j7b/mailgun/client/fault"
+	"github.com/j7b/mailgun/message"
+	"github.com/j7b/mailgun/suppression/bounce"
+)
+
+func send(c client.Caller) error {
+	msg, err := message.New(`sender@domain.fake`, `Hello`, `<b>hi</b>`, `a@example.com`)
+	if err != nil {
+		return err
+	}
+	_, err = msg.Send(c)
+	return err
+}
+
+func TestNth(t *testing.T) {
+	c := fake.New(t).Caller()
+	tr := fault.Inject(c, fault.Rule{Path: `/messages`, Nth: 2, Fault: fault.TooManyRequests(time.Second)})
+	if err := send(c); err != nil {
+		t.Fatal(err)
+	}
+	err := send(c)
+	if !errors.Is(err, client.ErrRateLimited) {
+		t.Fatal(`want`, client.ErrRateLimited, `got`, err)
+	}
+	if e := client.Err(err); e.Header.Get(`Retry-After`) != `1` {
+		t.Fatal(`want Retry-After 1 got`, e.Header)
+	}
+	if err = send(c); err != nil {
+		t.Fatal(err)
+	}
+	if n := tr.Calls(0); n != 3 {
+		t.Fatal(`want 3 calls got`, n)
+	}
+}
+
+func TestRetried(t *testing.T) {
+	s := fake.New(t)
+	c := s.Caller()
+	c.Retry = &client.Retry{Attempts: 3, Min: time.Millisecond, Post: true}
+	fault.Inject(c,
+		fault.Rule{Method: `POST`, Nth: 1, Fault: fault.Reset()},
+		fault.Rule{Method: `POST`, Nth: 2, Fault: fault.ServerError(502)},
+	)
+	if err := send(c); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.Messages()); n != 1 {
+		t.Fatal(`want 1 message got`, n)
+	}
+}
+
+func TestBodies(t *testing.T) {
+	c := fake.New(t).Caller()
+	api := bounce.Bounces(c)
+	if err := api.Add(`a@example.com`, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	tr := fault.Inject(c, fault.Rule{Method: `GET`, Nth: 1, Fault: fault.MalformedJSON()})
+	if _, err := api.List(); err == nil || client.Err(err) != nil {
+		t.Fatal(`want decode error got`, err)
+	}
+	tr.Add(fault.Rule{Method: `GET`, Nth: 1, Fault: fault.Truncate(10)})
+	if _, err := api.List(); err == nil {
+		t.Fatal(`want truncation error`)
+	}
+	if _, err := api.List(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	c := fake.New(t).Caller()
+	fault.Inject(c, fault.Rule{Probability: 1, Fault: fault.Delay(time.Second)})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := bounce.Bounces(c).WithContext(ctx).List(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(`want`, context.DeadlineExceeded, `got`, err)
+	}
+}
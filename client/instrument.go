@@ -0,0 +1,94 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Observation is the outcome of performing a Request.
+type Observation struct {
+	Method   string
+	Route    string        // path relative to the API version root, ids replaced
+	Family   string        // endpoint family, as for Limiter
+	Status   int           // response status code, zero if Err
+	Err      error         // transport or context error
+	Duration time.Duration // total, including retries
+	Retries  int
+}
+
+// Instrument observes performed Requests.
+type Instrument interface {
+	Observe(Observation)
+}
+
+// InstrumentFunc is an Instrument func.
+type InstrumentFunc func(Observation)
+
+// Observe implements Instrument.
+func (f InstrumentFunc) Observe(o Observation) {
+	f(o)
+}
+
+// static are path segments that are not ids.
+var static = map[string]bool{}
+
+func init() {
+	for _, s := range strings.Fields(`messages messages.mime events
+		bounces complaints unsubscribes whitelists tags tag stats total
+		aggregates countries providers devices domains webhooks lists
+		members members.json pages credentials connection tracking
+		click open unsubscribe verify ips pools templates versions
+		address validate validations bulk accounts routes limits keys
+		sending_queues envelopes dkim_authority dkim_selector
+		web_prefix subaccounts`) {
+		static[s] = true
+	}
+}
+
+// route returns pth, relative to the API version root, with
+// domains, addresses and other ids replaced by placeholders.
+func route(pth string) string {
+	if i := strings.IndexByte(pth, '?'); i >= 0 {
+		pth = pth[:i]
+	}
+	segs := strings.Split(strings.Trim(pth, `/`), `/`)
+	for i, s := range segs {
+		switch {
+		case static[s] || len(s) == 0:
+		case strings.IndexByte(s, '@') >= 0:
+			segs[i] = `{address}`
+		case i == 0 || segs[i-1] == Domains:
+			segs[i] = `{domain}`
+		default:
+			segs[i] = `{id}`
+		}
+	}
+	return strings.Join(segs, `/`)
+}
+
+func (r *Requester) instrument() Instrument {
+	if r == nil {
+		return nil
+	}
+	return r.Instrument
+}
+
+func (r *Request) observe(start time.Time, retries int, res *http.Response, err error) {
+	in := r.requester.instrument()
+	if in == nil {
+		return
+	}
+	o := Observation{
+		Method:   r.method,
+		Route:    r.route,
+		Family:   r.family,
+		Err:      err,
+		Duration: time.Since(start),
+		Retries:  retries,
+	}
+	if res != nil && err == nil {
+		o.Status = res.StatusCode
+	}
+	in.Observe(o)
+}
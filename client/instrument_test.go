@@ -0,0 +1,18 @@
+package client
+
+import "testing"
+
+func TestRoute(t *testing.T) {
+	for pth, want := range map[string]string{
+		`domain.test/bounces/a@b.c`:                `{domain}/bounces/{address}`,
+		`domains/domain.test/webhooks/click`:       `domains/{domain}/webhooks/click`,
+		`lists/l@d.c/members/m@d.c`:                `lists/{address}/members/{address}`,
+		`domain.test/tags/newsletter/stats?a=b`:    `{domain}/tags/{id}/stats`,
+		`domain.test/templates/welcome/versions/1`: `{domain}/templates/{id}/versions/{id}`,
+		`ips/127.0.0.1`:                            `ips/{id}`,
+	} {
+		if r := route(pth); r != want {
+			t.Fatal(`want`, want, `got`, r)
+		}
+	}
+}
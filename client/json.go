@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSON sets the payload of this Request to the JSON encoding
+// of v with Content-Type application/json, returning Request.
+// Precludes Form and Multipart methods.
+func (r *Request) JSON(v interface{}) *Request {
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.err(err)
+		return r
+	}
+	return r.SetHeader(`Content-Type`, `application/json`).Payload(bytes.NewReader(b))
+}
+
+// DecodeJSON performs r, decoding the field key of the JSON
+// object in the response as T, or the whole response if key
+// is zero-length. A missing field decodes as the zero T.
+// Pagers decoded as T retain the Caller of r.
+func DecodeJSON[T any](r *Request, key string) (T, error) {
+	var v T
+	if len(key) == 0 {
+		return v, r.Decode(&v)
+	}
+	var o map[string]json.RawMessage
+	if err := r.Decode(&o); err != nil {
+		return v, err
+	}
+	raw, ok := o[key]
+	if !ok {
+		return v, nil
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, err
+	}
+	r.setpager(&v)
+	return v, nil
+}
+
+// GetJSON performs a GET Request for uri with c, decoding
+// the result as with DecodeJSON.
+func GetJSON[T any](c Caller, key string, uri ...string) (T, error) {
+	return DecodeJSON[T](c.Get(uri...), key)
+}
+
+// PostJSON performs a POST Request for uri with c and the
+// JSON encoding of body, decoding the result as with
+// DecodeJSON.
+func PostJSON[T any](c Caller, key string, body interface{}, uri ...string) (T, error) {
+	return DecodeJSON[T](c.Post(uri...).JSON(body), key)
+}
+
+// PutJSON performs a PUT Request for uri with c and the
+// JSON encoding of body, decoding the result as with
+// DecodeJSON.
+func PutJSON[T any](c Caller, key string, body interface{}, uri ...string) (T, error) {
+	return DecodeJSON[T](c.Put(uri...).JSON(body), key)
+}
+
+// DeleteJSON performs a DELETE Request for uri with c,
+// decoding the result as with DecodeJSON.
+func DeleteJSON[T any](c Caller, key string, uri ...string) (T, error) {
+	return DecodeJSON[T](c.Delete(uri...), key)
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type page struct {
+	Items  []string `json:"items"`
+	caller Caller
+}
+
+func (p *page) SetCaller(c Caller) {
+	p.caller = c
+}
+
+func TestJSON(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var o map[string]string
+			if r.Header.Get(`Content-Type`) != `application/json` || json.NewDecoder(r.Body).Decode(&o) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{`template`: o})
+			return
+		}
+		w.Write([]byte(`{"items": ["a", "b"], "tag": {"tag": "t"}}`))
+	})
+	tag, err := GetJSON[struct{ Tag string }](c, `tag`, `tags`, `t`)
+	if err != nil || tag.Tag != `t` {
+		t.Fatal(`want tag t got`, tag, err)
+	}
+	p, err := GetJSON[*page](c, ``, `tags`)
+	if err != nil || len(p.Items) != 2 || p.caller != Caller(c) {
+		t.Fatal(`want page with caller got`, p, err)
+	}
+	missing, err := GetJSON[*page](c, `missing`, `tags`)
+	if err != nil || missing != nil {
+		t.Fatal(`want nil got`, missing, err)
+	}
+	tmpl, err := PostJSON[map[string]string](c, `template`, map[string]string{`name`: `n`}, `templates`)
+	if err != nil || tmpl[`name`] != `n` {
+		t.Fatal(`want template n got`, tmpl, err)
+	}
+	if err = c.Post(`templates`).JSON(func() {}).Err(); err == nil {
+		t.Fatal(`want encoding error`)
+	}
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint families, for Limiter.Family. The family of
+// a request is the resource it addresses, for example
+// "/domains/{domain}/webhooks" is Webhooks and
+// "{domain}/messages.mime" is Messages.
+const (
+	Messages     = `messages`
+	Webhooks     = `webhooks`
+	Lists        = `lists`
+	Domains      = `domains`
+	Events       = `events`
+	Bounces      = `bounces`
+	Complaints   = `complaints`
+	Unsubscribes = `unsubscribes`
+	Tags         = `tags`
+	Stats        = `stats`
+	IPs          = `ips`
+	Templates    = `templates`
+)
+
+type bucket struct {
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newbucket(n int, per time.Duration) *bucket {
+	if n < 1 || per <= 0 {
+		return nil
+	}
+	return &bucket{
+		rate:   float64(n) / per.Seconds(),
+		burst:  float64(n),
+		tokens: float64(n),
+	}
+}
+
+func (b *bucket) advance(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+func (b *bucket) delay() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Limiter is a client-side rate limiter. Requests take a
+// token from a bucket shared by all requests and from the
+// bucket of their endpoint family, if any. A Limiter may
+// be shared by Requesters and is safe for concurrent use.
+// The zero Limiter is limited only by family.
+type Limiter struct {
+	mu       sync.Mutex
+	global   *bucket
+	families map[string]*bucket
+}
+
+// NewLimiter returns a Limiter allowing n requests per
+// interval, in bursts of up to n. If n < 1 requests are
+// limited only by family.
+func NewLimiter(n int, per time.Duration) *Limiter {
+	return &Limiter{global: newbucket(n, per), families: make(map[string]*bucket)}
+}
+
+// Family limits requests to family to n per interval,
+// returning l.
+func (l *Limiter) Family(family string, n int, per time.Duration) *Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b := newbucket(n, per); b != nil {
+		if l.families == nil {
+			l.families = make(map[string]*bucket)
+		}
+		l.families[family] = b
+	} else {
+		delete(l.families, family)
+	}
+	return l
+}
+
+func (l *Limiter) buckets(family string) []*bucket {
+	var bs []*bucket
+	if l.global != nil {
+		bs = append(bs, l.global)
+	}
+	if b := l.families[family]; b != nil {
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// Wait blocks until a request to family is allowed or ctx
+// is done. If ctx has a deadline that would pass first,
+// Wait fails immediately with an error matching
+// ErrRateLimited.
+func (l *Limiter) Wait(ctx context.Context, family string) error {
+	l.mu.Lock()
+	now := time.Now()
+	bs := l.buckets(family)
+	var d time.Duration
+	for _, b := range bs {
+		b.advance(now)
+		if bd := b.delay(); bd > d {
+			d = bd
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(d).After(deadline) {
+		l.mu.Unlock()
+		return fmt.Errorf("limiter: %w: %s would wait %v", ErrRateLimited, family, d)
+	}
+	for _, b := range bs {
+		b.tokens--
+	}
+	l.mu.Unlock()
+	if err := sleep(ctx, d); err != nil {
+		l.mu.Lock()
+		for _, b := range bs {
+			if b.tokens++; b.tokens > b.burst {
+				b.tokens = b.burst
+			}
+		}
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// family returns the endpoint family of a path relative
+// to the API version root. A first segment naming domain,
+// or any other domain, is skipped.
+func family(pth, domain string) string {
+	if i := strings.IndexByte(pth, '?'); i >= 0 {
+		pth = pth[:i]
+	}
+	segs := strings.Split(strings.Trim(pth, `/`), `/`)
+	f := segs[0]
+	switch {
+	case (f == domain || strings.IndexByte(f, '.') > 0) && len(segs) > 1:
+		f = segs[1]
+	case f == Domains && len(segs) > 2:
+		f = segs[2]
+	}
+	if i := strings.IndexByte(f, '.'); i > 0 && f != domain {
+		f = f[:i]
+	}
+	return f
+}
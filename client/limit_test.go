@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFamily(t *testing.T) {
+	c := New(`https://api.mailgun.net/v3/`, `key`, `domain.test`)
+	for _, tc := range []struct {
+		uri  []string
+		want string
+	}{
+		{[]string{`messages`}, Messages},
+		{[]string{`messages.mime`}, Messages},
+		{[]string{`/domains`, `domain.test`, `webhooks`, `click`}, Webhooks},
+		{[]string{`/domains`, `domain.test`}, Domains},
+		{[]string{`/lists`, `list@domain.test`, `members.json`}, Lists},
+		{[]string{`bounces`, `a@b.c`}, Bounces},
+		{[]string{`/other.test`, `tags`}, Tags},
+		{[]string{`https://api.mailgun.net/v3/domain.test/events?page=next`}, Events},
+	} {
+		if f := c.Get(tc.uri...).family; f != tc.want {
+			t.Fatal(tc.uri, `want`, tc.want, `got`, f)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(0, 0).Family(Webhooks, 2, 100*time.Millisecond)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, Webhooks); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Fatal(`third request not delayed`, d)
+	}
+	if err := l.Wait(ctx, Messages); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatal(`unlimited family delayed`, d)
+	}
+	short, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	l.Wait(ctx, Webhooks)
+	if err := l.Wait(short, Webhooks); !errors.Is(err, ErrRateLimited) {
+		t.Fatal(`want`, ErrRateLimited, `got`, err)
+	}
+}
+
+func TestLimiterZero(t *testing.T) {
+	var l Limiter
+	if err := l.Wait(context.Background(), Messages); err != nil {
+		t.Fatal(err)
+	}
+	l.Family(Messages, 1, time.Hour)
+	if err := l.Wait(context.Background(), Messages); err != nil {
+		t.Fatal(err)
+	}
+	short, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := l.Wait(short, Messages); !errors.Is(err, ErrRateLimited) {
+		t.Fatal(`want`, ErrRateLimited, `got`, err)
+	}
+}
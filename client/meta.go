@@ -0,0 +1,130 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Meta is response metadata.
+type Meta struct {
+	Status     string
+	StatusCode int
+	Header     http.Header
+	RequestID  string    // X-Mailgun-Request-Id or X-Request-Id
+	Limit      int       // X-RateLimit-Limit, -1 if absent
+	Remaining  int       // X-RateLimit-Remaining, -1 if absent
+	Reset      time.Time // X-RateLimit-Reset, zero if absent
+}
+
+// NewMeta returns the Meta of res, nil if res is nil.
+func NewMeta(res *http.Response) *Meta {
+	if res == nil {
+		return nil
+	}
+	return meta(res.Status, res.StatusCode, res.Header)
+}
+
+func meta(status string, code int, h http.Header) *Meta {
+	if h == nil {
+		h = make(http.Header)
+	}
+	m := &Meta{
+		Status:     status,
+		StatusCode: code,
+		Header:     h,
+		RequestID:  h.Get(`X-Mailgun-Request-Id`),
+		Limit:      header(h, `X-RateLimit-Limit`),
+		Remaining:  header(h, `X-RateLimit-Remaining`),
+		Reset:      reset(h.Get(`X-RateLimit-Reset`)),
+	}
+	if len(m.RequestID) == 0 {
+		m.RequestID = h.Get(`X-Request-Id`)
+	}
+	return m
+}
+
+func header(h http.Header, k string) int {
+	n, err := strconv.Atoi(h.Get(k))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// reset parses a reset time, which may be Unix seconds
+// or milliseconds, seconds from now or an HTTP date.
+func reset(v string) time.Time {
+	if len(v) == 0 {
+		return time.Time{}
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		switch {
+		case n > 1e12:
+			return time.UnixMilli(n)
+		case n > 1e9:
+			return time.Unix(n, 0)
+		default:
+			return time.Now().Add(time.Duration(n) * time.Second)
+		}
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// RetryAfter returns the delay requested by the Retry-After
+// header, which may be seconds or an HTTP date, failing that
+// for a 429 response or exhausted rate limit, the time until
+// the rate limit resets. RetryAfter of nil is zero.
+func (m *Meta) RetryAfter() time.Duration {
+	if m == nil {
+		return 0
+	}
+	if v := m.Header.Get(`Retry-After`); len(v) > 0 {
+		if s, err := strconv.Atoi(v); err == nil && s > 0 {
+			return time.Duration(s) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+		return 0
+	}
+	if (m.StatusCode == http.StatusTooManyRequests || m.Remaining == 0) && !m.Reset.IsZero() {
+		return time.Until(m.Reset)
+	}
+	return 0
+}
+
+// errmeta returns the Meta of an Error, nil if err isn't one.
+func errmeta(err error) *Meta {
+	var e *Error
+	if errors.As(err, &e) {
+		return meta(e.Status, e.StatusCode, e.Header)
+	}
+	return nil
+}
+
+// Meta performs this Request, returning the response Meta,
+// which is also returned with an Error from the endpoint.
+func (r *Request) Meta() (*Meta, error) {
+	res, err := r.Do()
+	if err != nil {
+		return errmeta(err), err
+	}
+	return NewMeta(res), res.Body.Close()
+}
+
+// DecodeMeta decodes the result of performing r to i like
+// Decode, returning the response Meta, which is also
+// returned with an Error from the endpoint.
+func (r *Request) DecodeMeta(i interface{}) (*Meta, error) {
+	res, err := r.Do()
+	if err != nil {
+		return errmeta(err), err
+	}
+	defer res.Body.Close()
+	return NewMeta(res), r.decode(res, i)
+}
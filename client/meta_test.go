@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMeta(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(`X-Mailgun-Request-Id`, `req-1`)
+		w.Header().Set(`X-RateLimit-Limit`, `300`)
+		w.Header().Set(`X-RateLimit-Remaining`, `0`)
+		w.Header().Set(`X-RateLimit-Reset`, strconv.FormatInt(reset, 10))
+		if r.URL.Path == `/v3/domain.test/missing` {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{"message": "ok"}`))
+	})
+	var o struct{ Message string }
+	m, err := c.Get(`tags`).DecodeMeta(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Message != `ok` || m.StatusCode != 200 || m.RequestID != `req-1` {
+		t.Fatal(`want decoded body and meta got`, o, m)
+	}
+	if m.Limit != 300 || m.Remaining != 0 || m.Reset.Unix() != reset {
+		t.Fatal(`want rate limit meta got`, m)
+	}
+	if d := m.RetryAfter(); d < 58*time.Second || d > time.Minute {
+		t.Fatal(`want about a minute got`, d)
+	}
+	m, err = c.Get(`missing`).Meta()
+	if err == nil || m == nil || m.StatusCode != 404 || m.RequestID != `req-1` {
+		t.Fatal(`want 404 meta with error got`, m, err)
+	}
+	h := http.Header{`X-Ratelimit-Remaining`: {`0`}, `X-Ratelimit-Reset`: {strconv.FormatInt(reset, 10)}}
+	if d := retryafter(&http.Response{StatusCode: http.StatusServiceUnavailable, Header: h}); d != 0 {
+		t.Fatal(`want no reset wait for 503 got`, d)
+	}
+	if d := retryafter(&http.Response{StatusCode: http.StatusTooManyRequests, Header: h}); d < 58*time.Second {
+		t.Fatal(`want reset wait for 429 got`, d)
+	}
+	if m = NewMeta(&http.Response{Header: make(http.Header)}); m.Limit != -1 || !m.Reset.IsZero() || m.RetryAfter() != 0 {
+		t.Fatal(`want absent rate limit got`, m)
+	}
+}
@@ -0,0 +1,72 @@
+// Package metrics implements a client.Instrument publishing
+// API usage with package expvar.
+/*
+Each method and route, such as "GET {domain}/bounces/{address}",
+has an expvar.Map of counters: requests, errors (transport
+errors and error statuses), retries, duration_ns (total) and
+a count per status code such as status_429.
+
+	r := client.New(endpoint, key, domain)
+	r.Instrument = metrics.New(`mailgun`)
+
+The counters are served as JSON at /debug/vars by the
+http.DefaultServeMux.
+*/
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+
+	"github.com/j7b/mailgun/client"
+)
+
+// Expvar is a client.Instrument publishing to an expvar.Map.
+type Expvar struct {
+	m  *expvar.Map
+	mu sync.Mutex
+}
+
+var _ = client.Instrument(&Expvar{})
+
+// New returns an Expvar publishing as name. Like
+// expvar.NewMap, New panics if name is already in use.
+func New(name string) *Expvar {
+	return &Expvar{m: expvar.NewMap(name)}
+}
+
+// NewMap returns an Expvar publishing to m.
+func NewMap(m *expvar.Map) *Expvar {
+	return &Expvar{m: m}
+}
+
+// Map returns the expvar.Map of e.
+func (e *Expvar) Map() *expvar.Map {
+	return e.m
+}
+
+func (e *Expvar) route(key string) *expvar.Map {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if m, ok := e.m.Get(key).(*expvar.Map); ok {
+		return m
+	}
+	m := new(expvar.Map).Init()
+	e.m.Set(key, m)
+	return m
+}
+
+// Observe implements client.Instrument.
+func (e *Expvar) Observe(o client.Observation) {
+	m := e.route(o.Method + ` ` + o.Route)
+	m.Add(`requests`, 1)
+	m.Add(`retries`, int64(o.Retries))
+	m.Add(`duration_ns`, int64(o.Duration))
+	if o.Err != nil || o.Status > 399 {
+		m.Add(`errors`, 1)
+	}
+	if o.Status > 0 {
+		m.Add(fmt.Sprintf(`status_%d`, o.Status), 1)
+	}
+}
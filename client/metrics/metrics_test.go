@@ -0,0 +1,40 @@
+package metrics_test
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/fake"
+	"github.com/j7b/mailgun/client/fault"
+	"github.com/j7b/mailgun/client/metrics"
+	"github.com/j7b/mailgun/suppression/bounce"
+)
+
+func TestExpvar(t *testing.T) {
+	c := fake.New(t).Caller()
+	c.Retry = &client.Retry{Attempts: 2, Min: time.Millisecond}
+	e := metrics.NewMap(new(expvar.Map).Init())
+	c.Instrument = e
+	fault.Inject(c, fault.Rule{Method: `GET`, Nth: 1, Fault: fault.ServerError(503)})
+	api := bounce.Bounces(c)
+	if _, err := api.Get(`a@example.com`); err == nil {
+		t.Fatal(`want not found`)
+	}
+	if err := api.Add(`a@example.com`, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	get, ok := e.Map().Get(`GET {domain}/bounces/{address}`).(*expvar.Map)
+	if !ok {
+		t.Fatal(`want route map got`, e.Map())
+	}
+	for k, want := range map[string]string{`requests`: `1`, `retries`: `1`, `errors`: `1`, `status_404`: `1`} {
+		if v := get.Get(k); v == nil || v.String() != want {
+			t.Fatal(k, `want`, want, `got`, v)
+		}
+	}
+	if post, ok := e.Map().Get(`POST {domain}/bounces`).(*expvar.Map); !ok || post.Get(`status_200`).String() != `1` {
+		t.Fatal(`want POST route got`, e.Map())
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Handler performs an http.Request.
+type Handler func(*http.Request) (*http.Response, error)
+
+// Middleware wraps a Handler. Middleware sees each attempt
+// of a Request, including its headers and basic auth, and
+// the response or error that resulted. Middleware should
+// not consume the response body without replacing it.
+type Middleware func(Handler) Handler
+
+// Header returns Middleware that sets header k to v on
+// each request.
+func Header(k, v string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set(k, v)
+			return next(req)
+		}
+	}
+}
+
+// redacted returns the headers of req with the API key removed.
+func redacted(h http.Header) http.Header {
+	h = h.Clone()
+	if len(h.Get(`Authorization`)) > 0 {
+		h.Set(`Authorization`, `[redacted]`)
+	}
+	return h
+}
+
+// Logging returns Middleware that logs each request and its
+// result to l. Results include the request ID, if any. The
+// Authorization header, which carries the API key, is redacted.
+func Logging(l *log.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			l.Println(req.Method, req.URL, redacted(req.Header))
+			start := time.Now()
+			res, err := next(req)
+			if err != nil {
+				l.Println(req.Method, req.URL, err, time.Since(start))
+				return res, err
+			}
+			result := []interface{}{req.Method, req.URL, res.Status, time.Since(start)}
+			if id := NewMeta(res).RequestID; len(id) > 0 {
+				result = append(result, id)
+			}
+			l.Println(result...)
+			return res, err
+		}
+	}
+}
+
+// Debug returns Middleware like Logging that also logs
+// response bodies.
+func Debug(l *log.Logger) Middleware {
+	logging := Logging(l)
+	return func(next Handler) Handler {
+		return logging(func(req *http.Request) (*http.Response, error) {
+			res, err := next(req)
+			if err != nil {
+				return res, err
+			}
+			defer res.Body.Close()
+			buf := new(bytes.Buffer)
+			if _, err = io.Copy(buf, res.Body); err != nil {
+				return nil, err
+			}
+			l.Println(buf.String())
+			res.Body = ioutil.NopCloser(buf)
+			return res, nil
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(`X-Test`) != `yes` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"message": "ok"}`))
+	})
+	c.APIKey = `secret-key`
+	buf := new(bytes.Buffer)
+	var order []string
+	mark := func(s string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, s)
+				return next(req)
+			}
+		}
+	}
+	c.Middleware = []Middleware{mark(`a`), Debug(log.New(buf, ``, 0)), Header(`X-Test`, `yes`), mark(`b`)}
+	var o struct {
+		Message string `json:"message"`
+	}
+	if err := c.Get(`bounces`).Decode(&o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Message != `ok` {
+		t.Fatal(`body not restored after logging, got`, o.Message)
+	}
+	if strings.Join(order, ``) != `ab` {
+		t.Fatal(`want order ab got`, order)
+	}
+	s := buf.String()
+	if !strings.Contains(s, `200 OK`) || !strings.Contains(s, `{"message": "ok"}`) {
+		t.Fatal(`incomplete log`, s)
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(`api:secret-key`))
+	if !strings.Contains(s, `[redacted]`) || strings.Contains(s, auth) {
+		t.Fatal(`key not redacted`, s)
+	}
+}
@@ -1,4 +1,9 @@
 // Package mock implements a mock client for testing.
+/*
+The mock client serves canned responses from files in the
+_mock directory beside this package's source. Package fake
+implements a stateful alternative.
+*/
 package mock
 
 import (
@@ -6,6 +11,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/j7b/mailgun/client"
@@ -28,14 +34,11 @@ func (rt *rt) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 func mock(t *testing.T) *http.Client {
-	gp := os.Getenv(`GOPATH`)
-	if len(gp) == 0 {
-		t.Fatal(`GOPATH not set`)
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal(`mock: source directory unknown`)
 	}
-	if l := len(filepath.SplitList(gp)); l != 1 {
-		t.Fatal(`GOPATH appears split`)
-	}
-	mockdir := filepath.Join(gp, `src`, `github.com`, `j7b`, `mailgun`, `client`, `mock`)
+	mockdir := filepath.Dir(file)
 	fi, err := os.Stat(mockdir)
 	if err != nil {
 		t.Fatal(err)
@@ -49,6 +52,7 @@ func mock(t *testing.T) *http.Client {
 	return c
 }
 
+// Client returns a Caller serving canned responses.
 func Client(t *testing.T) client.Caller {
 	c := &client.Requester{}
 	c.Endpoint = `_mock/`
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+)
+
+// Multipart sets f to write the multipart/form-data body of
+// this Request following any Form values, returning Request.
+// The body is streamed through a pipe while the request is in
+// flight, so neither it nor its Content-Length is computed
+// beforehand. If the Request is retried f is called again for
+// each attempt, Requests with non-replayable parts should be
+// marked Retryable(false). Precludes Payload method.
+func (r *Request) Multipart(f func(*multipart.Writer) error) *Request {
+	r.multipart = f
+	return r
+}
+
+func (r *Request) writeparts(w *multipart.Writer) error {
+	for k, v := range r.form {
+		for _, v := range v {
+			if err := w.WriteField(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	if r.multipart != nil {
+		if err := r.multipart(w); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// bodyerror is an error writing a request body, such as
+// reading an attachment, which isn't retried.
+type bodyerror struct {
+	err error
+}
+
+func (e bodyerror) Error() string {
+	return `request body: ` + e.err.Error()
+}
+
+func (e bodyerror) Unwrap() error {
+	return e.err
+}
+
+// stream returns a function returning the multipart body
+// of r, written by a goroutine to a pipe, and the boundary
+// shared by every body it returns.
+func (r *Request) stream() (func() (io.ReadCloser, error), string) {
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+	return func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			w := multipart.NewWriter(pw)
+			err := w.SetBoundary(boundary)
+			if err == nil {
+				err = r.writeparts(w)
+			}
+			if err != nil {
+				err = bodyerror{err}
+			}
+			pw.CloseWithError(err)
+		}()
+		return pr, nil
+	}, boundary
+}
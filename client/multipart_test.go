@@ -0,0 +1,79 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultipartBodyError(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{}`))
+	})
+	c.Retry = &Retry{Attempts: 3, Min: time.Millisecond, Max: 2 * time.Millisecond, Post: true}
+	handled := 0
+	c.Middleware = []Middleware{func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			handled++
+			return next(req)
+		}
+	}}
+	broken := errors.New(`disk on fire`)
+	_, err := c.Post(`messages`).Multipart(func(w *multipart.Writer) error {
+		fw, err := w.CreateFormFile(`attachment`, `a.txt`)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(fw, strings.NewReader(strings.Repeat(`x`, 1<<16))); err != nil {
+			return err
+		}
+		return broken
+	}).Do()
+	if !errors.Is(err, broken) {
+		t.Fatal(`want`, broken, `got`, err)
+	}
+	if handled != 1 {
+		t.Fatal(`want 1 attempt got`, handled)
+	}
+}
+
+func TestMultipartUnread(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	refused := errors.New(`refused`)
+	c.Middleware = []Middleware{func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			return nil, refused
+		}
+	}}
+	done := make(chan error, 1)
+	_, err := c.Post(`messages`).Multipart(func(w *multipart.Writer) error {
+		fw, err := w.CreateFormFile(`attachment`, `big.bin`)
+		if err == nil {
+			_, err = io.Copy(fw, io.LimitReader(zeros{}, 1<<20))
+		}
+		done <- err
+		return err
+	}).Do()
+	if !errors.Is(err, refused) {
+		t.Fatal(`want`, refused, `got`, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`body writer not released`)
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -5,8 +5,10 @@ Not intended for direct use by user code.
 package pager
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"iter"
 
 	"github.com/j7b/mailgun/client"
 )
@@ -50,6 +52,13 @@ func (p *Paging) get(uri string, i interface{}) error {
 	return p.c.Get(uri).Decode(i)
 }
 
+func (p *Paging) getContext(ctx context.Context, uri string, i interface{}) error {
+	if p.c == nil {
+		return fmt.Errorf("paging: caller not set")
+	}
+	return client.WithContext(p.c, ctx).Get(uri).Decode(i)
+}
+
 // Next decodes the next url to i. Returns
 // io.EOF if p is nil or N is empty.
 func (p *Paging) Next(i interface{}) error {
@@ -59,6 +68,15 @@ func (p *Paging) Next(i interface{}) error {
 	return p.get(p.N, i)
 }
 
+// NextContext decodes the next url to i using ctx. Returns
+// io.EOF if p is nil or N is empty.
+func (p *Paging) NextContext(ctx context.Context, i interface{}) error {
+	if p == nil || len(p.N) == 0 {
+		return io.EOF
+	}
+	return p.getContext(ctx, p.N, i)
+}
+
 // Previous decodes the previous url to i. Returns
 // io.EOF if p is nil or P is empty.
 func (p *Paging) Previous(i interface{}) error {
@@ -68,6 +86,15 @@ func (p *Paging) Previous(i interface{}) error {
 	return p.get(p.P, i)
 }
 
+// PreviousContext decodes the previous url to i using ctx. Returns
+// io.EOF if p is nil or P is empty.
+func (p *Paging) PreviousContext(ctx context.Context, i interface{}) error {
+	if p == nil || len(p.P) == 0 {
+		return io.EOF
+	}
+	return p.getContext(ctx, p.P, i)
+}
+
 // First decodes the first url to i. Returns
 // io.EOF if p is nil or F is empty.
 func (p *Paging) First(i interface{}) error {
@@ -77,6 +104,15 @@ func (p *Paging) First(i interface{}) error {
 	return p.get(p.F, i)
 }
 
+// FirstContext decodes the first url to i using ctx. Returns
+// io.EOF if p is nil or F is empty.
+func (p *Paging) FirstContext(ctx context.Context, i interface{}) error {
+	if p == nil || len(p.F) == 0 {
+		return io.EOF
+	}
+	return p.getContext(ctx, p.F, i)
+}
+
 // Last decodes the last url to i. Returns
 // io.EOF if p is nil or L is empty.
 func (p *Paging) Last(i interface{}) error {
@@ -86,5 +122,65 @@ func (p *Paging) Last(i interface{}) error {
 	return p.get(p.L, i)
 }
 
+// LastContext decodes the last url to i using ctx. Returns
+// io.EOF if p is nil or L is empty.
+func (p *Paging) LastContext(ctx context.Context, i interface{}) error {
+	if p == nil || len(p.L) == 0 {
+		return io.EOF
+	}
+	return p.getContext(ctx, p.L, i)
+}
+
+// Seq returns an iterator over items and the items of the
+// pages following p, for use with range. Iteration stops at
+// an empty page or at a next URL already visited, the API is
+// known to repeat next URLs. An error ends iteration and is
+// yielded with the zero T.
+func Seq[T any](items []T, p *Paging) iter.Seq2[T, error] {
+	return SeqFunc(items, p, func(c client.Caller, uri string) ([]T, *Paging, error) {
+		var page struct {
+			Items []T `json:"items"`
+			Pager
+		}
+		if err := c.Get(uri).Decode(&page); err != nil {
+			return nil, nil, err
+		}
+		return page.Items, page.Paging, nil
+	})
+}
+
+// SeqFunc is like Seq, calling next to retrieve the
+// page at uri with the Caller of p.
+func SeqFunc[T any](items []T, p *Paging, next func(c client.Caller, uri string) ([]T, *Paging, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		seen := make(map[string]bool)
+		for {
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) == 0 || p == nil || len(p.N) == 0 || seen[p.N] {
+				return
+			}
+			if p.c == nil {
+				yield(zero, fmt.Errorf("paging: caller not set"))
+				return
+			}
+			seen[p.N] = true
+			c := p.c
+			var err error
+			if items, p, err = next(c, p.N); err != nil {
+				yield(zero, err)
+				return
+			}
+			if p != nil && p.c == nil {
+				p.c = c
+			}
+		}
+	}
+}
+
 // BUG(j7b): Paging might be inconsistently expressed by the API, and
 // it's awful hard to test without 2 pages worth of data.
@@ -0,0 +1,93 @@
+package pager
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/j7b/mailgun/client"
+)
+
+// pages serves page n as items [n*2, n*2+1], with next
+// pointing to page n+1 until last, which points to itself.
+func pages(t *testing.T, last int, fail int) (*client.Requester, *int) {
+	calls := new(int)
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		var n int
+		fmt.Sscan(r.URL.Query().Get(`page`), &n)
+		if n == fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		next := n + 1
+		if n == last {
+			next = n
+		}
+		fmt.Fprintf(w, `{"items":[%d,%d],"paging":{"next":"%s/v3/domain.test/things?page=%d"}}`, n*2, n*2+1, s.URL, next)
+	}))
+	t.Cleanup(s.Close)
+	return client.New(s.URL+`/v3/`, `key`, `domain.test`), calls
+}
+
+type page struct {
+	Items []int `json:"items"`
+	Pager
+}
+
+func first(t *testing.T, c client.Caller) *page {
+	var p *page
+	if err := c.Get(`things`).SetQuery(`page`, `0`).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSeq(t *testing.T) {
+	c, calls := pages(t, 2, -1)
+	p := first(t, c)
+	var got []int
+	for i, err := range Seq(p.Items, p.Paging) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, i)
+	}
+	if fmt.Sprint(got) != `[0 1 2 3 4 5]` {
+		t.Fatal(`want [0 1 2 3 4 5] got`, got)
+	}
+	if *calls != 3 {
+		t.Fatal(`want 3 requests got`, *calls)
+	}
+}
+
+func TestSeqBreak(t *testing.T) {
+	c, calls := pages(t, 100, -1)
+	p := first(t, c)
+	for i := range Seq(p.Items, p.Paging) {
+		if i == 2 {
+			break
+		}
+	}
+	if *calls != 2 {
+		t.Fatal(`want 2 requests got`, *calls)
+	}
+}
+
+func TestSeqError(t *testing.T) {
+	c, _ := pages(t, 100, 1)
+	p := first(t, c)
+	var n int
+	var err error
+	for _, err = range Seq(p.Items, p.Paging) {
+		if err != nil {
+			break
+		}
+		n++
+	}
+	if n != 2 || client.Err(err) == nil {
+		t.Fatal(`want 2 items and client.Error got`, n, err)
+	}
+}
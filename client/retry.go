@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Retry is a retry policy. Requests are retried when the
+// endpoint responds 429, 500, 502, 503 or 504, or when the
+// request fails in transport other than writing its body,
+// after waiting the greater of an exponential, jittered
+// backoff and any Retry-After header. GET, PUT and DELETE
+// requests are retried; POST requests are retried only if
+// Post is true or the Request is marked with Retryable.
+type Retry struct {
+	Attempts int           // maximum attempts, including the first
+	Min      time.Duration // initial backoff, 500ms if zero
+	Max      time.Duration // maximum backoff, 30s if zero
+	Post     bool          // retry POST requests
+}
+
+func (p *Retry) attempts() int {
+	if p == nil || p.Attempts < 1 {
+		return 1
+	}
+	return p.Attempts
+}
+
+func (p *Retry) backoff(attempt int) time.Duration {
+	min, max := p.Min, p.Max
+	if min <= 0 {
+		min = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	d := min
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (p *Retry) method(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return p.Post
+	}
+	return false
+}
+
+func retryable(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var be bodyerror
+		return !errors.As(err, &be) && !errors.Is(err, ErrNotReplayable)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryafter returns the delay requested by res. Only a
+// 429 response waits for the rate limit to reset, others
+// with an exhausted rate limit back off as usual.
+func retryafter(res *http.Response) time.Duration {
+	m := NewMeta(res)
+	if m != nil && m.StatusCode != http.StatusTooManyRequests {
+		m.Reset = time.Time{}
+	}
+	return m.RetryAfter()
+}
+
+func discard(res *http.Response) {
+	if res == nil {
+		return
+	}
+	io.CopyN(ioutil.Discard, res.Body, 4096)
+	res.Body.Close()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Span is a tracing span.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End(err error)
+}
+
+// Tracer starts a Span for each performed Request, as a
+// child of any span in the context of the Request, so
+// for example message.SendContext is traced within the
+// span of ctx.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Injector is implemented by Tracers that propagate
+// the span of ctx in request headers.
+type Injector interface {
+	Inject(ctx context.Context, h http.Header)
+}
+
+// Span attributes.
+const (
+	AttrMethod = `http.method`
+	AttrRoute  = `http.route`
+	AttrStatus = `http.status_code`
+	AttrDomain = `mailgun.domain`
+)
+
+func (r *Requester) tracer() Tracer {
+	if r == nil {
+		return nil
+	}
+	return r.Tracer
+}
+
+func (r *Request) start(t Tracer, ctx context.Context) (context.Context, Span) {
+	ctx, span := t.Start(ctx, `mailgun `+r.method+` `+r.route)
+	span.SetAttribute(AttrMethod, r.method)
+	span.SetAttribute(AttrRoute, r.route)
+	span.SetAttribute(AttrDomain, r.client.Domain())
+	return ctx, span
+}
+
+func end(span Span, res *http.Response, err error) {
+	if res != nil {
+		span.SetAttribute(AttrStatus, res.StatusCode)
+	} else if e := Err(err); e != nil {
+		span.SetAttribute(AttrStatus, e.StatusCode)
+	}
+	span.End(err)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type span struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *span) SetAttribute(k string, v interface{}) {
+	s.attrs[k] = v
+}
+
+func (s *span) End(err error) {
+	s.err, s.ended = err, true
+}
+
+type tracer []*span
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &span{name: name, attrs: make(map[string]interface{})}
+	*t = append(*t, s)
+	return ctx, s
+}
+
+func (t *tracer) Inject(ctx context.Context, h http.Header) {
+	h.Set(`X-Trace`, (*t)[len(*t)-1].name)
+}
+
+func TestTracer(t *testing.T) {
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(`X-Trace`) != `mailgun GET {domain}/bounces/{address}` {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		if r.URL.Path == `/v3/domain.test/bounces/missing@b.c` {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	})
+	tr := new(tracer)
+	c.Tracer = tr
+	if err := c.Get(`bounces`, `a@b.c`).Err(); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Get(`bounces`, `missing@b.c`).Err()
+	if len(*tr) != 2 {
+		t.Fatal(`want 2 spans got`, len(*tr))
+	}
+	s := (*tr)[0]
+	if !s.ended || s.err != nil || s.attrs[AttrStatus] != 200 || s.attrs[AttrDomain] != `domain.test` || s.attrs[AttrMethod] != `GET` {
+		t.Fatal(`want ended span with attributes got`, s)
+	}
+	if s = (*tr)[1]; !errors.Is(s.err, ErrNotFound) || err == nil || s.attrs[AttrStatus] != 404 {
+		t.Fatal(`want span ended with`, err, `got`, s)
+	}
+}
@@ -0,0 +1,64 @@
+// Package tracing adapts package runtime/trace to client.Tracer.
+/*
+Each performed Request is a trace task named for its method and
+route, with its attributes and any error logged to the task, so
+API calls appear within the tasks of their context in
+"go tool trace":
+
+	r := client.New(endpoint, key, domain)
+	r.Tracer = tracing.Tracer{}
+
+Propagate may set trace headers, such as traceparent, for the
+context of each Request.
+*/
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/trace"
+
+	"github.com/j7b/mailgun/client"
+)
+
+// Tracer is a client.Tracer using runtime/trace tasks.
+type Tracer struct {
+	Propagate func(ctx context.Context, h http.Header)
+}
+
+var (
+	_ = client.Tracer(Tracer{})
+	_ = client.Injector(Tracer{})
+)
+
+type span struct {
+	ctx  context.Context
+	task *trace.Task
+}
+
+// Start implements client.Tracer.
+func (t Tracer) Start(ctx context.Context, name string) (context.Context, client.Span) {
+	ctx, task := trace.NewTask(ctx, name)
+	return ctx, span{ctx: ctx, task: task}
+}
+
+// Inject implements client.Injector.
+func (t Tracer) Inject(ctx context.Context, h http.Header) {
+	if t.Propagate != nil {
+		t.Propagate(ctx, h)
+	}
+}
+
+// SetAttribute implements client.Span.
+func (s span) SetAttribute(key string, value interface{}) {
+	trace.Log(s.ctx, key, fmt.Sprint(value))
+}
+
+// End implements client.Span.
+func (s span) End(err error) {
+	if err != nil {
+		trace.Log(s.ctx, `error`, err.Error())
+	}
+	s.task.End()
+}
@@ -0,0 +1,41 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/trace"
+	"testing"
+
+	"github.com/j7b/mailgun/client/capture"
+	"github.com/j7b/mailgun/client/tracing"
+	"github.com/j7b/mailgun/message"
+)
+
+func TestTracer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := trace.Start(buf); err != nil {
+		t.Skip(err)
+	}
+	c := capture.New(t)
+	c.Tracer = tracing.Tracer{Propagate: func(ctx context.Context, h http.Header) {
+		h.Set(`Traceparent`, `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`)
+	}}
+	ctx, task := trace.NewTask(context.Background(), `send`)
+	msg, err := message.New(`a@domain.fake`, `Hi`, `<b>hi</b>`, `b@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = msg.SendContext(ctx, c)
+	task.End()
+	trace.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := c.Last().Header.Get(`Traceparent`); len(h) == 0 {
+		t.Fatal(`want traceparent header`)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`mailgun POST {domain}/messages`)) {
+		t.Fatal(`want task in trace`)
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isversion reports whether seg is an API version such as v3.
+func isversion(seg string) bool {
+	if len(seg) < 2 || seg[0] != 'v' {
+		return false
+	}
+	for _, c := range seg[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// version replaces the first API version segment of the
+// path of endpoint with vn.
+func version(endpoint string, n int) string {
+	o := origin(endpoint)
+	pth, rest := endpoint[len(o):], ``
+	if i := strings.IndexAny(pth, `?#`); i >= 0 {
+		pth, rest = pth[:i], pth[i:]
+	}
+	segs := strings.Split(pth, `/`)
+	for i, seg := range segs {
+		if isversion(seg) {
+			segs[i] = fmt.Sprintf(`v%d`, n)
+			break
+		}
+	}
+	return o + strings.Join(segs, `/`) + rest
+}
+
+// Version sets the API version of this Request, such as 4
+// for /v4/ endpoints, returning Request. Relative, domain
+// relative and absolute URIs are all rewritten in place of
+// the version of the Endpoint.
+func (r *Request) Version(n int) *Request {
+	if n < 1 {
+		r.err(fmt.Errorf("request: invalid API version %d", n))
+		return r
+	}
+	r.endpoint = version(r.endpoint, n)
+	return r
+}
+
+type versioncaller struct {
+	Caller
+	n int
+}
+
+func (c versioncaller) bind(r *Request) *Request {
+	r.client = c
+	return r.Version(c.n)
+}
+
+// Get returns a GET Request.
+func (c versioncaller) Get(uri ...string) *Request {
+	return c.bind(c.Caller.Get(uri...))
+}
+
+// Post returns a POST Request.
+func (c versioncaller) Post(uri ...string) *Request {
+	return c.bind(c.Caller.Post(uri...))
+}
+
+// Put returns a PUT Request.
+func (c versioncaller) Put(uri ...string) *Request {
+	return c.bind(c.Caller.Put(uri...))
+}
+
+// Delete returns a DELETE Request.
+func (c versioncaller) Delete(uri ...string) *Request {
+	return c.bind(c.Caller.Delete(uri...))
+}
+
+// WithVersion returns a Caller whose Requests use API
+// version n, so WithVersion(c, 4).Get(`/address/validate`)
+// requests /v4/address/validate.
+func WithVersion(c Caller, n int) Caller {
+	if vc, ok := c.(versioncaller); ok {
+		c = vc.Caller
+	}
+	return versioncaller{Caller: c, n: n}
+}
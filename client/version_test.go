@@ -0,0 +1,36 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	var paths []string
+	c := server(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{}`))
+	})
+	for _, tc := range []struct {
+		req  *Request
+		want string
+	}{
+		{c.Get(`messages`).Version(4), c.Endpoint[:len(c.Endpoint)-3] + `v4/domain.test/messages`},
+		{WithVersion(c, 4).Get(`/address/validate`), c.Endpoint[:len(c.Endpoint)-3] + `v4/address/validate`},
+		{WithVersion(WithVersion(c, 4), 5).Get(`/accounts`, `webhooks`).SetQuery(`a`, `b`), c.Endpoint[:len(c.Endpoint)-3] + `v5/accounts/webhooks?a=b`},
+		{c.Get(`https://api.mailgun.net/v3/domain.test/events?page=2`).Version(4), c.Endpoint[:len(c.Endpoint)-3] + `v4/domain.test/events?page=2`},
+	} {
+		if u := tc.req.URL(); u != tc.want {
+			t.Fatal(`want`, tc.want, `got`, u)
+		}
+		if err := tc.req.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if paths[1] != `/v4/address/validate` {
+		t.Fatal(`want /v4/address/validate got`, paths[1])
+	}
+	if err := c.Get(`messages`).Version(0).Err(); err == nil {
+		t.Fatal(`want invalid version error`)
+	}
+}
@@ -0,0 +1,303 @@
+package mailgun
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/j7b/mailgun/client"
+)
+
+// Config configures a Client. Secrets may be read from
+// files named by APIKeyFile and WebhookSigningKeyFile
+// rather than set directly. WebhookSigningKey is for
+// validating webhooks, as with handler.Key, and isn't
+// used by the Client.
+type Config struct {
+	APIKey                string
+	APIKeyFile            string
+	Domain                string
+	BaseURL               string
+	WebhookSigningKey     string
+	WebhookSigningKeyFile string
+	Timeout               time.Duration // HTTP client timeout, none if zero
+	Retry                 *client.Retry
+	RateLimit             int           // requests per RatePeriod, unlimited if zero
+	RatePeriod            time.Duration // a second if zero
+	TestMode              bool          // send messages in test mode
+}
+
+// keys are the configuration keys for files, and with
+// prefix MAILGUN_ in upper case for the environment. A
+// secret and its file replace each other, so a later
+// layer can set either.
+var keys = map[string]func(c *Config, v string) error{
+	`key`:                      func(c *Config, v string) error { c.APIKey, c.APIKeyFile = v, ``; return nil },
+	`key_file`:                 func(c *Config, v string) error { c.APIKey, c.APIKeyFile = ``, v; return nil },
+	`domain`:                   func(c *Config, v string) error { c.Domain = v; return nil },
+	`api_base`:                 func(c *Config, v string) error { c.BaseURL = v; return nil },
+	`region`:                   setregion,
+	`webhook_signing_key`:      func(c *Config, v string) error { c.WebhookSigningKey, c.WebhookSigningKeyFile = v, ``; return nil },
+	`webhook_signing_key_file`: func(c *Config, v string) error { c.WebhookSigningKey, c.WebhookSigningKeyFile = ``, v; return nil },
+	`timeout`:                  func(c *Config, v string) error { return duration(&c.Timeout, v) },
+	`retry_attempts`:           func(c *Config, v string) error { return integer(&c.retry().Attempts, v) },
+	`retry_min`:                func(c *Config, v string) error { return duration(&c.retry().Min, v) },
+	`retry_max`:                func(c *Config, v string) error { return duration(&c.retry().Max, v) },
+	`retry_post`:               func(c *Config, v string) error { return boolean(&c.retry().Post, v) },
+	`rate_limit`:               func(c *Config, v string) error { return integer(&c.RateLimit, v) },
+	`rate_period`:              func(c *Config, v string) error { return duration(&c.RatePeriod, v) },
+	`test_mode`:                func(c *Config, v string) error { return boolean(&c.TestMode, v) },
+}
+
+func setregion(c *Config, v string) error {
+	switch strings.ToLower(v) {
+	case `us`:
+		c.BaseURL = string(RegionUS)
+	case `eu`:
+		c.BaseURL = string(RegionEU)
+	default:
+		return fmt.Errorf("unknown region %q", v)
+	}
+	return nil
+}
+
+func duration(d *time.Duration, v string) (err error) {
+	*d, err = time.ParseDuration(v)
+	return
+}
+
+func integer(i *int, v string) (err error) {
+	*i, err = strconv.Atoi(v)
+	return
+}
+
+func boolean(b *bool, v string) (err error) {
+	*b, err = strconv.ParseBool(v)
+	return
+}
+
+func (c *Config) retry() *client.Retry {
+	if c.Retry == nil {
+		c.Retry = new(client.Retry)
+	}
+	return c.Retry
+}
+
+// Set sets the configuration key to v.
+func (c *Config) Set(key, v string) error {
+	set, ok := keys[key]
+	if !ok {
+		return fmt.Errorf("config: unknown key %q", key)
+	}
+	if err := set(c, v); err != nil {
+		return fmt.Errorf("config: %s: %w", key, err)
+	}
+	return nil
+}
+
+// Env sets c from environment variables named as the
+// configuration keys in upper case with prefix MAILGUN_,
+// such as MAILGUN_KEY_FILE and MAILGUN_RETRY_ATTEMPTS.
+func (c *Config) Env() error {
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if v, ok := os.LookupEnv(`MAILGUN_` + strings.ToUpper(k)); ok && len(v) > 0 {
+			if err := c.Set(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// File sets c from the file at path, either a JSON object
+// or lines of key = value, with # comments, keyed by the
+// configuration keys:
+//
+//	key_file = /run/secrets/mailgun
+//	domain = mg.example.com
+//	region = eu
+//	timeout = 30s
+//	retry_attempts = 3
+//	rate_limit = 100
+//
+// Relative secret file paths are relative to path.
+func (c *Config) File(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	vals, err := parse(b)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	for _, kv := range vals {
+		k, v := kv[0], kv[1]
+		if strings.HasSuffix(k, `_file`) && len(v) > 0 && !filepath.IsAbs(v) {
+			v = filepath.Join(filepath.Dir(path), v)
+		}
+		if err = c.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parse(b []byte) ([][2]string, error) {
+	var vals [][2]string
+	if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '{' {
+		var m map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(t))
+		d.UseNumber()
+		if err := d.Decode(&m); err != nil {
+			return nil, err
+		}
+		for k, v := range m {
+			vals = append(vals, [2]string{k, fmt.Sprint(v)})
+		}
+		sort.Slice(vals, func(i, j int) bool { return vals[i][0] < vals[j][0] })
+		return vals, nil
+	}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: want key = value", n)
+		}
+		v := strings.TrimSpace(line[i+1:])
+		if uq, err := strconv.Unquote(v); err == nil {
+			v = uq
+		}
+		vals = append(vals, [2]string{strings.TrimSpace(line[:i]), v})
+	}
+	return vals, s.Err()
+}
+
+// Load returns a Config set from the file at path, if
+// path isn't zero-length, then the environment, then opts.
+func Load(path string, opts ...Option) (*Config, error) {
+	c := new(Config)
+	if len(path) > 0 {
+		if err := c.File(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Env(); err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// secret returns v, failing that the trimmed contents
+// of the file at path, if path isn't zero-length.
+func secret(v, path string) (string, error) {
+	if len(v) > 0 || len(path) == 0 {
+		return v, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ``, err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// Key returns the API key of c.
+func (c *Config) Key() (string, error) {
+	return secret(c.APIKey, c.APIKeyFile)
+}
+
+// SigningKey returns the webhook signing key of c.
+func (c *Config) SigningKey() (string, error) {
+	return secret(c.WebhookSigningKey, c.WebhookSigningKeyFile)
+}
+
+// Client returns a Client configured by c.
+func (c *Config) Client() (*Client, error) {
+	key, err := c.Key()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("config: API key not configured")
+	}
+	if len(c.Domain) == 0 {
+		return nil, fmt.Errorf("config: domain not configured")
+	}
+	base := prefix
+	if len(c.BaseURL) > 0 {
+		if base, err = baseurl(c.BaseURL); err != nil {
+			return nil, err
+		}
+	}
+	r := client.New(base, key, c.Domain)
+	if c.Timeout > 0 {
+		r.Client = &http.Client{Timeout: c.Timeout}
+	}
+	if c.Retry != nil {
+		p := *c.Retry
+		r.Retry = &p
+	}
+	if c.RateLimit > 0 {
+		per := c.RatePeriod
+		if per <= 0 {
+			per = time.Second
+		}
+		r.Limit = client.NewLimiter(c.RateLimit, per)
+	}
+	return &Client{Caller: r, testmode: c.TestMode}, nil
+}
+
+// APIKeyFile reads the API key from the file at path.
+func APIKeyFile(path string) Option {
+	return func(c *Config) {
+		c.APIKey, c.APIKeyFile = ``, path
+	}
+}
+
+// Timeout sets the HTTP client timeout.
+func Timeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = d
+	}
+}
+
+// RetryPolicy sets the retry policy.
+func RetryPolicy(p client.Retry) Option {
+	return func(c *Config) {
+		c.Retry = &p
+	}
+}
+
+// RateLimit limits requests to n per interval.
+func RateLimit(n int, per time.Duration) Option {
+	return func(c *Config) {
+		c.RateLimit, c.RatePeriod = n, per
+	}
+}
+
+// TestMode sends messages in test mode.
+func TestMode() Option {
+	return func(c *Config) {
+		c.TestMode = true
+	}
+}
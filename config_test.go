@@ -0,0 +1,124 @@
+package mailgun
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/capture"
+	"github.com/j7b/mailgun/message"
+)
+
+func TestLoad(t *testing.T) {
+	for _, k := range []string{`MAILGUN_KEY`, `MAILGUN_DOMAIN`, `MAILGUN_API_BASE`, `MAILGUN_TIMEOUT`} {
+		t.Setenv(k, ``)
+	}
+	dir := t.TempDir()
+	write := func(name, s string) string {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write(`secret`, "key-secret\n")
+	write(`signing`, "sign-secret\n")
+	conf := write(`mailgun.conf`, `# mailgun
+key_file = secret
+webhook_signing_key_file = signing
+domain = "mg.example.com"
+region = eu
+timeout = 10s
+retry_attempts = 3
+rate_limit = 100
+test_mode = true
+`)
+	t.Setenv(`MAILGUN_TIMEOUT`, `20s`)
+	c, err := Load(conf, RateLimit(50, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Timeout != 20*time.Second || c.RateLimit != 50 || c.Retry.Attempts != 3 {
+		t.Fatal(`want env and options to override file got`, c)
+	}
+	if k, err := c.SigningKey(); err != nil || k != `sign-secret` {
+		t.Fatal(`want sign-secret got`, k, err)
+	}
+	cl, err := c.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := cl.Caller.(*client.Requester)
+	if r.Key() != `key-secret` || r.Domain() != `mg.example.com` || r.Endpoint != string(RegionEU) {
+		t.Fatal(`want configured Requester got`, r.Key(), r.Domain(), r.Endpoint)
+	}
+	if r.HTTPClient().Timeout != 20*time.Second || r.Retry.Attempts != 3 || r.Limit == nil || !cl.testmode {
+		t.Fatal(`want timeout, retry, limit and test mode got`, r, cl.testmode)
+	}
+	js := write(`mailgun.json`, `{"key": "k", "domain": "d", "retry_attempts": 2, "retry_post": true, "rate_limit": 1000000}`)
+	if c, err = Load(js); err != nil {
+		t.Fatal(err)
+	}
+	if c.APIKey != `k` || c.Retry.Attempts != 2 || !c.Retry.Post || c.RateLimit != 1000000 {
+		t.Fatal(`want JSON config got`, c)
+	}
+	if _, err = Load(write(`bad.conf`, `keyfile = x`)); err == nil {
+		t.Fatal(`want unknown key error`)
+	}
+	t.Setenv(`MAILGUN_KEY_FILE`, filepath.Join(dir, `secret`))
+	if c, err = Load(write(`key.conf`, `key = file-key`)); err != nil {
+		t.Fatal(err)
+	}
+	if k, err := c.Key(); err != nil || k != `key-secret` {
+		t.Fatal(`want env key file over file key got`, k, err)
+	}
+	t.Setenv(`MAILGUN_KEY_FILE`, ``)
+	t.Setenv(`MAILGUN_KEY`, `env-key`)
+	if c, err = Load(conf); err != nil {
+		t.Fatal(err)
+	}
+	if k, err := c.Key(); err != nil || k != `env-key` {
+		t.Fatal(`want env key over file key_file got`, k, err)
+	}
+	os.Remove(filepath.Join(dir, `secret`))
+	if _, err = New(``, `d`, APIKeyFile(filepath.Join(dir, `secret`))); err == nil {
+		t.Fatal(`want missing key file error`)
+	}
+}
+
+func TestTestMode(t *testing.T) {
+	c := capture.New(t)
+	cl := &Client{Caller: c, testmode: true}
+	msg, err := message.NewMIME(`b@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = msg.SendMIME(cl, strings.NewReader("From: a@domain.fake\r\nSubject: x\r\n\r\nhi\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if msg.TestMode != nil || c.LastMessage().Form.Get(`o:testmode`) != `yes` {
+		t.Fatal(`want SendMIME in test mode got`, c.LastMessage().Form)
+	}
+	if msg, err = message.New(`a@domain.fake`, `s`, `hi`, `b@example.com`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = msg.SendContext(context.Background(), cl); err != nil {
+		t.Fatal(err)
+	}
+	if c.LastMessage().Form.Get(`o:testmode`) != `yes` {
+		t.Fatal(`want SendContext in test mode got`, c.LastMessage().Form)
+	}
+	no := false
+	msg.TestMode = &no
+	if _, err = msg.Send(cl.ForDomain(`domain.fake`)); err != nil {
+		t.Fatal(err)
+	}
+	if c.LastMessage().Form.Get(`o:testmode`) != `no` {
+		t.Fatal(`want message TestMode to win got`, c.LastMessage().Form)
+	}
+}
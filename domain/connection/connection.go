@@ -15,10 +15,7 @@ type Connection struct {
 
 // Settings retrieve connection settings for API domain.
 func Settings(c client.Caller) (*Connection, error) {
-	var con struct {
-		Connection *Connection `json:"connection,omitempty"`
-	}
-	return con.Connection, c.Get(`/domains`, c.Domain(), `connection`).Decode(&con)
+	return client.GetJSON[*Connection](c, `connection`, `/domains`, c.Domain(), `connection`)
 }
 
 // Update updates connection parameters with con.
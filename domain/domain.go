@@ -2,6 +2,7 @@
 package domain
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/j7b/mailgun/client"
@@ -19,6 +20,11 @@ func API(c client.Caller) Caller {
 	return Caller{c: c}
 }
 
+// WithContext returns a copy of c whose requests use ctx.
+func (c Caller) WithContext(ctx context.Context) Caller {
+	return Caller{c: client.WithContext(c.c, ctx)}
+}
+
 type spamAction string
 
 // Spam action for inbound mail.
@@ -36,11 +36,7 @@ type Tracking struct {
 
 // Settings retrieves tracking settings for API domain.
 func Settings(c client.Caller) (*Tracking, error) {
-	var t struct {
-		Tracking *Tracking `json:"tracking,omitempty"`
-	}
-	return t.Tracking, c.Get(`/domains`, c.Domain(), `tracking`).
-		Decode(&t)
+	return client.GetJSON[*Tracking](c, `tracking`, `/domains`, c.Domain(), `tracking`)
 }
 
 func puts(c client.Caller, name string, active bool) error {
@@ -16,8 +16,10 @@ https://documentation.mailgun.com/en/latest/api-events.html#event-polling
 package event
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"log"
 	"net/http"
 	"time"
@@ -65,6 +67,19 @@ func (e *Events) List() []types.Interface {
 	return e.list
 }
 
+// All returns an iterator over the events of e
+// and subsequent pages.
+func (e *Events) All() iter.Seq2[types.Interface, error] {
+	return pager.SeqFunc(e.list, e.Paging, func(c client.Caller, uri string) ([]types.Interface, *pager.Paging, error) {
+		res, err := c.Get(uri).Do()
+		events, err := parseresults(c, res, err)
+		if err != nil {
+			return nil, nil, err
+		}
+		return events.list, events.Paging, nil
+	})
+}
+
 // FilterField holds a filter expression.
 type FilterField interface {
 	name() string
@@ -182,6 +197,7 @@ func parseresults(c client.Caller, res *http.Response, err error) (*Events, erro
 		}
 		gens[i] = gen
 	}
+	o.Pager.SetCaller(c)
 	events := Events{Pager: o.Pager}
 	for i, g := range gens {
 		data := []byte(o.Events[i])
@@ -274,3 +290,9 @@ func (c *Client) Query(begin *time.Time, end *time.Time, ascending *bool, filter
 	res, err := req.Do()
 	return parseresults(c.c, res, err)
 }
+
+// QueryContext executes a query using ctx and the parameters provided.
+// Subsequent pages of the returned Events also use ctx.
+func (c *Client) QueryContext(ctx context.Context, begin *time.Time, end *time.Time, ascending *bool, filters ...FilterField) (*Events, error) {
+	return Queries(client.WithContext(c.c, ctx)).Query(begin, end, ascending, filters...)
+}
@@ -4,14 +4,20 @@ The functions in this package are for operations on mailing lists.
 The Membership type, created with the Manager function,
 performs operations on members of mailing lists.
 
+Callers that need cancellation or deadlines should pass
+a Caller returned by client.WithContext to the functions
+in this package, or use Membership.WithContext.
+
 Documentation for this endpoint is at
 https://documentation.mailgun.com/en/latest/api-mailinglists.html
 */
 package list
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 
 	"github.com/j7b/mailgun/client"
 	"github.com/j7b/mailgun/client/pager"
@@ -74,6 +80,12 @@ func (p *Page) First() (*Page, error) {
 	return p.page(p.Pager.Paging.First)
 }
 
+// All returns an iterator over the mailing lists
+// of p and subsequent pages.
+func (p *Page) All() iter.Seq2[List, error] {
+	return pager.Seq(p.Lists, p.Paging)
+}
+
 // List is a mailing list.
 type List struct {
 	AccessLevel string `json:"access_level"`  // : "everyone",
@@ -248,6 +260,11 @@ func (m *Membership) Add(l member.List) error {
 	return nil
 }
 
+// WithContext returns a copy of m whose requests use ctx.
+func (m *Membership) WithContext(ctx context.Context) *Membership {
+	return &Membership{caller: client.WithContext(m.caller, ctx), address: m.address}
+}
+
 // Manager returns a member manager for listaddress.
 func Manager(c client.Caller, listaddress string) *Membership {
 	return &Membership{caller: c, address: listaddress}
@@ -15,6 +15,7 @@ package member
 
 import (
 	"fmt"
+	"iter"
 
 	"github.com/j7b/mailgun/client/pager"
 )
@@ -25,6 +26,12 @@ type Page struct {
 	pager.Pager
 }
 
+// All returns an iterator over the members of p
+// and subsequent pages.
+func (p *Page) All() iter.Seq2[Member, error] {
+	return pager.Seq(p.Members, p.Paging)
+}
+
 // Member of a list.
 type Member struct {
 	Address    string                 `json:"address"`
@@ -7,18 +7,82 @@ are found in subdirectories of this package.
 package mailgun
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/j7b/mailgun/client"
 	"github.com/j7b/mailgun/message"
 )
 
-var prefix = `https://api.mailgun.net/v3/`
+var prefix = string(RegionUS)
+
+// Region is the API base URL of a region.
+type Region string
+
+// API regions.
+const (
+	RegionUS = Region(`https://api.mailgun.net/v3/`)
+	RegionEU = Region(`https://api.eu.mailgun.net/v3/`)
+)
+
+// Option is an option for New or Load.
+type Option func(*Config)
+
+// WithRegion selects the API region.
+func WithRegion(r Region) Option {
+	return func(c *Config) {
+		c.BaseURL = string(r)
+	}
+}
+
+// BaseURL overrides the API base URL. If
+// base has no path the v3 API is assumed, so
+// "https://api.eu.mailgun.net" is equivalent
+// to WithRegion(RegionEU).
+func BaseURL(base string) Option {
+	return func(c *Config) {
+		c.BaseURL = base
+	}
+}
+
+func baseurl(base string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return ``, err
+	}
+	if (u.Scheme != `https` && u.Scheme != `http`) || len(u.Host) == 0 {
+		return ``, fmt.Errorf("base URL %q not absolute", base)
+	}
+	if u.Path == `` || u.Path == `/` {
+		u.Path = `/v3/`
+	}
+	if !strings.HasSuffix(u.Path, `/`) {
+		u.Path += `/`
+	}
+	u.RawQuery, u.Fragment = ``, ``
+	return u.String(), nil
+}
 
 // Client is a mailgun client.
 type Client struct {
 	client.Caller
+	testmode bool
+}
+
+func (c *Client) with(cc client.Caller) *Client {
+	return &Client{Caller: cc, testmode: c.testmode}
+}
+
+// TestMode reports whether messages sent with c, by any
+// of the send methods of package message, are sent in test
+// mode, unless they set their own TestMode. Callers derived
+// from c by package client, such as with WithContext, don't
+// report test mode, ForDomain and SendContext do.
+func (c *Client) TestMode() bool {
+	return c.testmode
 }
 
 // Send sends an HTML email, returning id.
@@ -34,23 +98,55 @@ func (c *Client) Send(from, subject, html string, to ...string) (id string, err
 	return o.ID, nil
 }
 
+// SendContext sends an HTML email using ctx, returning id.
+func (c *Client) SendContext(ctx context.Context, from, subject, html string, to ...string) (id string, err error) {
+	return c.with(client.WithContext(c.Caller, ctx)).Send(from, subject, html, to...)
+}
+
+// ForDomain returns a Client for domain sharing the
+// HTTP client, limiter and middleware of c.
+func (c *Client) ForDomain(domain string) *Client {
+	return c.with(client.WithDomain(c.Caller, domain))
+}
+
+// EachDomain calls op with a Client for each of domains,
+// at most n at once or all at once if n < 1, returning
+// the error of each domain, nil if op succeeded.
+func (c *Client) EachDomain(domains []string, n int, op func(c *Client) error) map[string]error {
+	res := client.EachDomain(c.Caller, domains, n, func(cc client.Caller) (struct{}, error) {
+		return struct{}{}, op(c.with(cc))
+	})
+	errs := make(map[string]error, len(res))
+	for _, r := range res {
+		errs[r.Domain] = r.Err
+	}
+	return errs
+}
+
 // New returns a Client for apikey and domain.
 // If apikey is zero-length, attempts to use environment
 // variable MAILGUN_KEY, failing that returns error.
 // If domain is zero-length, attempts to use environment
 // variable MAILGUN_DOMAIN, failing that returns error.
-func New(apikey, domain string) (*Client, error) {
-	if len(apikey) == 0 {
-		apikey = os.Getenv("MAILGUN_KEY")
-		if len(apikey) == 0 {
-			return nil, fmt.Errorf("MAILGUN_KEY not set, apikey not supplied")
-		}
-	}
-	if len(domain) == 0 {
-		domain = os.Getenv("MAILGUN_DOMAIN")
-		if len(domain) == 0 {
-			return nil, fmt.Errorf("MAILGUN_DOMAIN not set, domain not supplied")
-		}
-	}
-	return &Client{Caller: client.New(prefix, apikey, domain)}, nil
+// The API base URL is selected by opts, failing that
+// environment variable MAILGUN_API_BASE, failing that
+// the US region. Load reads further configuration.
+func New(apikey, domain string, opts ...Option) (*Client, error) {
+	cfg := &Config{APIKey: apikey, Domain: domain, BaseURL: os.Getenv("MAILGUN_API_BASE")}
+	if len(cfg.APIKey) == 0 {
+		cfg.APIKey = os.Getenv("MAILGUN_KEY")
+	}
+	if len(cfg.Domain) == 0 {
+		cfg.Domain = os.Getenv("MAILGUN_DOMAIN")
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if len(cfg.APIKey) == 0 && len(cfg.APIKeyFile) == 0 {
+		return nil, fmt.Errorf("MAILGUN_KEY not set, apikey not supplied")
+	}
+	if len(cfg.Domain) == 0 {
+		return nil, fmt.Errorf("MAILGUN_DOMAIN not set, domain not supplied")
+	}
+	return cfg.Client()
 }
@@ -1,6 +1,10 @@
 package mailgun
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/j7b/mailgun/client"
+)
 
 func TestNew(t *testing.T) {
 	c, err := New(`asdfe`, `asdf`)
@@ -11,3 +15,51 @@ func TestNew(t *testing.T) {
 		t.Fatal(`want "asdfe" got`, c.Key())
 	}
 }
+
+func endpoint(t *testing.T, c *Client) string {
+	r, ok := c.Caller.(*client.Requester)
+	if !ok {
+		t.Fatalf(`want *client.Requester got %T`, c.Caller)
+	}
+	return r.Endpoint
+}
+
+func TestBase(t *testing.T) {
+	t.Setenv(`MAILGUN_API_BASE`, ``)
+	for _, tc := range []struct {
+		env  string
+		opts []Option
+		want string
+	}{
+		{``, nil, `https://api.mailgun.net/v3/`},
+		{``, []Option{WithRegion(RegionEU)}, `https://api.eu.mailgun.net/v3/`},
+		{`https://api.eu.mailgun.net`, nil, `https://api.eu.mailgun.net/v3/`},
+		{`https://api.eu.mailgun.net`, []Option{BaseURL(`http://localhost:8080/v3`)}, `http://localhost:8080/v3/`},
+	} {
+		t.Setenv(`MAILGUN_API_BASE`, tc.env)
+		c, err := New(`key`, `domain`, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := endpoint(t, c); e != tc.want {
+			t.Fatal(`want`, tc.want, `got`, e)
+		}
+	}
+	if _, err := New(`key`, `domain`, BaseURL(`api.mailgun.net`)); err == nil {
+		t.Fatal(`want error for relative base`)
+	}
+}
+
+func TestForDomain(t *testing.T) {
+	c, err := New(`key`, `domain`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := c.ForDomain(`other`)
+	if d.Domain() != `other` || c.Domain() != `domain` {
+		t.Fatal(`want other and domain got`, d.Domain(), c.Domain())
+	}
+	if endpoint(t, d) != endpoint(t, c) {
+		t.Fatal(`want shared endpoint`)
+	}
+}
@@ -4,11 +4,29 @@ The Send method sends a Message to the recipients in
 recipmap. The recipmap key should be an email address
 (bare or RFC5322 compliant). The value (map[string]interface{})
 will be marshalled to the recipient-variables
-field.
+field. Recipient maps larger than the endpoint allows
+are sent in chunks, with SendAll reporting the message
+ID of each chunk and the recipients of those that failed:
+
+	res, err := batch.SendAll(c, msg, recipmap, 4)
+	if err != nil {
+		return err
+	}
+	if failed := res.Failed(); failed != nil {
+		res, err = batch.SendAll(c, msg, failed, 4)
+	}
 */
 package batch
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
 	"github.com/j7b/mailgun/client"
 	"github.com/j7b/mailgun/message"
 )
@@ -22,8 +40,187 @@ func rv(i interface{}, recipmap map[string]map[string]interface{}) {
 	rv.SetRecipVars(recipmap)
 }
 
-// Send sends m to recipmap.
-func Send(c client.Caller, m *message.Message, recipmap map[string]map[string]interface{}) (*message.Response, error) {
+// Size is the most recipients in a chunk, including the
+// cc and bcc recipients of the Message.
+const Size = message.MaxRecipients
+
+// size returns the number of recipmap recipients in a
+// chunk of m.
+func size(m *message.Message) (int, error) {
+	_, cc, bcc := m.Recipients()
+	n := Size - len(cc) - len(bcc)
+	if n < 1 {
+		return 0, fmt.Errorf("batch: %d cc and bcc recipients leave no room in a chunk of %d", len(cc)+len(bcc), Size)
+	}
+	return n, nil
+}
+
+// Chunk is the result of sending to a chunk of recipients.
+type Chunk struct {
+	Recipients map[string]map[string]interface{}
+	ID         string
+	Err        error
+}
+
+// Result is the results of sending to each chunk of a
+// recipient map, in order.
+type Result []Chunk
+
+// IDs returns the message IDs of the chunks sent.
+func (r Result) IDs() []string {
+	var ids []string
+	for _, c := range r {
+		if c.Err == nil {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+// Err returns the errors of r joined, nil if none.
+func (r Result) Err() error {
+	var errs []error
+	for i, c := range r {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("chunk %d: %w", i, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Failed returns the recipients of the chunks that
+// failed, for sending again, nil if none.
+func (r Result) Failed() map[string]map[string]interface{} {
+	var failed map[string]map[string]interface{}
+	for _, c := range r {
+		if c.Err == nil {
+			continue
+		}
+		if failed == nil {
+			failed = make(map[string]map[string]interface{})
+		}
+		for k, v := range c.Recipients {
+			failed[k] = v
+		}
+	}
+	return failed
+}
+
+// Error is a failure of some chunks sent by Send, with
+// the Result of every chunk.
+type Error struct {
+	Result Result
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("batch: %d of %d chunks failed: %v", len(e.Result)-len(e.Result.IDs()), len(e.Result), e.Result.Err())
+}
+
+// Unwrap returns the errors of the failed chunks.
+func (e *Error) Unwrap() error {
+	return e.Result.Err()
+}
+
+// chunks splits recipmap into maps of at most size
+// recipients, in recipient order.
+func chunks(recipmap map[string]map[string]interface{}, size int) []map[string]map[string]interface{} {
+	recips := make([]string, 0, len(recipmap))
+	for k := range recipmap {
+		recips = append(recips, k)
+	}
+	sort.Strings(recips)
+	var cs []map[string]map[string]interface{}
+	for len(recips) > 0 {
+		n := min(size, len(recips))
+		c := make(map[string]map[string]interface{}, n)
+		for _, k := range recips[:n] {
+			c[k] = recipmap[k]
+		}
+		cs = append(cs, c)
+		recips = recips[n:]
+	}
+	return cs
+}
+
+// buffer reads files into memory, returning a function
+// returning new readers of them.
+func buffer(files map[string]io.Reader) (func() map[string]io.Reader, error) {
+	b := make(map[string][]byte, len(files))
+	for k, r := range files {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("batch: %s: %w", k, err)
+		}
+		b[k] = data
+	}
+	return func() map[string]io.Reader {
+		m := make(map[string]io.Reader, len(b))
+		for k, data := range b {
+			m[k] = bytes.NewReader(data)
+		}
+		return m
+	}, nil
+}
+
+// SendAll sends m to recipmap in chunks of at most Size
+// recipients, each with its own recipient-variables, at
+// most n at once or all at once if n < 1. The cc and bcc
+// recipients of m receive every chunk and count towards
+// its Size. Attachments and Inlines are read into memory
+// to be sent with each chunk, m isn't modified. The Result
+// reports the message ID of each chunk sent and the
+// recipients of any that failed. If no chunk is sent an
+// error is returned, with the Result if any was tried.
+func SendAll(c client.Caller, m *message.Message, recipmap map[string]map[string]interface{}, n int) (Result, error) {
+	if len(recipmap) == 0 {
+		return nil, errors.New("batch: no recipients")
+	}
+	sz, err := size(m)
+	if err != nil {
+		return nil, err
+	}
+	cs := chunks(recipmap, sz)
+	attachments, err := buffer(m.Attachments)
+	if err != nil {
+		return nil, err
+	}
+	inlines, err := buffer(m.Inlines)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(cs) {
+		n = len(cs)
+	}
+	res := make(Result, len(cs))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, recips := range cs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			cm := *m
+			cm.Attachments, cm.Inlines = attachments(), inlines()
+			res[i] = Chunk{Recipients: recips}
+			re, err := send(c, &cm, recips)
+			if err != nil {
+				res[i].Err = err
+				return
+			}
+			res[i].ID = re.ID
+		}()
+	}
+	wg.Wait()
+	if len(res.IDs()) == 0 {
+		return res, res.Err()
+	}
+	return res, nil
+}
+
+func send(c client.Caller, m *message.Message, recipmap map[string]map[string]interface{}) (*message.Response, error) {
 	rv(m, recipmap)
 	recips := make([]string, 0, len(recipmap))
 	for k := range recipmap {
@@ -35,6 +232,26 @@ func Send(c client.Caller, m *message.Message, recipmap map[string]map[string]in
 	return m.Send(c)
 }
 
+// Send sends m to recipmap. If recipmap doesn't fit in a
+// single chunk it is sent with SendAll, one chunk at a time,
+// and the Response has the message ID of the first chunk;
+// use SendAll for the ID of every chunk. If any chunk fails
+// the error is an *Error, with the Result reporting the
+// chunks sent and the recipients to send to again.
+func Send(c client.Caller, m *message.Message, recipmap map[string]map[string]interface{}) (*message.Response, error) {
+	if sz, err := size(m); err != nil || len(recipmap) <= sz {
+		return send(c, m, recipmap)
+	}
+	res, err := SendAll(c, m, recipmap, 1)
+	if res == nil {
+		return nil, err
+	}
+	if res.Err() != nil {
+		return nil, &Error{Result: res}
+	}
+	return &message.Response{ID: res[0].ID}, nil
+}
+
 // BUG(j7b): The results of using non-builtin types in the
 // map[string]interface{} can be astonishing and the
 // templating supported by the endpoint is very limited.
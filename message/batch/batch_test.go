@@ -1,8 +1,16 @@
 package batch
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/j7b/mailgun/client/fake"
+	"github.com/j7b/mailgun/client/fault"
 	"github.com/j7b/mailgun/client/mock"
 	"github.com/j7b/mailgun/message"
 )
@@ -23,3 +31,121 @@ func TestSend(t *testing.T) {
 		}
 	}
 }
+
+func recipients(n int) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		m[fmt.Sprintf(`r%04d@roll.org`, i)] = map[string]interface{}{`n`: i}
+	}
+	return m
+}
+
+func TestSendAll(t *testing.T) {
+	s := fake.New(t)
+	c := s.Caller()
+	msg, err := message.New(`Mailgun <postmaster@sandbox.mailgun.org>`, `Hey bud!`, `awesome`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Attachments[`a.txt`] = ioutil.NopCloser(strings.NewReader(`attached`))
+	res, err := SendAll(c, msg, recipients(2500), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 || len(res.IDs()) != 3 {
+		t.Fatal(`want 3 chunks got`, len(res), res.IDs())
+	}
+	sent := s.Messages()
+	if len(sent) != 3 {
+		t.Fatal(`want 3 messages got`, len(sent))
+	}
+	total := 0
+	for _, m := range sent {
+		total += len(m.To)
+		if len(m.Files) != 1 || string(m.Files[0].Data) != `attached` {
+			t.Fatal(`attachment not sent with chunk`, m.Files)
+		}
+		var vars map[string]interface{}
+		if err = json.Unmarshal([]byte(m.Form.Get(`recipient-variables`)), &vars); err != nil {
+			t.Fatal(err)
+		}
+		if len(vars) != len(m.To) {
+			t.Fatal(`recipient-variables want`, len(m.To), `got`, len(vars))
+		}
+	}
+	if total != 2500 {
+		t.Fatal(`want 2500 recipients got`, total)
+	}
+	fault.Inject(c, fault.Rule{Path: `/messages`, Nth: 2, Fault: fault.Status(http.StatusBadRequest)})
+	res, err = SendAll(c, msg, recipients(2500), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Err() == nil || len(res.IDs()) != 2 {
+		t.Fatal(`want one failed chunk got`, res.Err(), res.IDs())
+	}
+	failed := res.Failed()
+	if len(failed) != Size {
+		t.Fatal(`want`, Size, `failed got`, len(failed))
+	}
+	if _, ok := failed[`r1000@roll.org`]; !ok {
+		t.Fatal(`want second chunk failed`)
+	}
+	if _, err = Send(c, msg, recipients(1500)); err != nil {
+		t.Fatal(err)
+	}
+	fault.Inject(c, fault.Rule{Path: `/messages`, Nth: 2, Fault: fault.Status(http.StatusBadRequest)})
+	_, err = Send(c, msg, recipients(2500))
+	var be *Error
+	if !errors.As(err, &be) || len(be.Result.IDs()) != 2 || len(be.Result.Failed()) != Size {
+		t.Fatal(`want Error with 2 sent chunks and 1 failed got`, err)
+	}
+	if _, err = SendAll(c, msg, nil, 0); err == nil {
+		t.Fatal(`want error with no recipients`)
+	}
+}
+
+func TestSendAllCopies(t *testing.T) {
+	s := fake.New(t)
+	c := s.Caller()
+	msg, err := message.New(`Mailgun <postmaster@sandbox.mailgun.org>`, `Hey bud!`, `awesome`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.CC(`cc@roll.org`)
+	msg.BCC(`bcc@roll.org`)
+	res, err := SendAll(c, msg, recipients(1500), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.IDs()) != 2 {
+		t.Fatal(`want 2 chunks got`, res.IDs())
+	}
+	for _, m := range s.Messages() {
+		if n := len(m.To) + len(m.CC) + len(m.BCC); n > Size || len(m.CC) != 1 || len(m.BCC) != 1 {
+			t.Fatal(`chunk has`, len(m.To), len(m.CC), len(m.BCC), `recipients`)
+		}
+	}
+	if _, err = Send(c, msg, recipients(999)); err != nil {
+		t.Fatal(err)
+	}
+	fault.Inject(c, fault.Rule{Path: `/messages`, Fault: fault.Status(http.StatusBadRequest)})
+	if res, err = SendAll(c, msg, recipients(1500), 0); err == nil || len(res) != 2 {
+		t.Fatal(`want error and 2 failed chunks got`, err, len(res))
+	}
+	full := make([]string, Size)
+	for i := range full {
+		full[i] = fmt.Sprintf(`cc%04d@roll.org`, i)
+	}
+	msg.BCC()
+	msg.CC(full...)
+	if _, err = SendAll(c, msg, recipients(1), 0); err == nil {
+		t.Fatal(`want error with no room for recipients`)
+	}
+}
@@ -2,7 +2,7 @@
 package message
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -39,34 +39,42 @@ func (r *recipvars) SetRecipVars(m map[string]map[string]interface{}) {
 
 // Message is an email message. Methods with
 // slice parameters do not retain references
-// to those slices.
+// to those slices. If Template is not zero-length
+// the message body is rendered from the stored
+// template of that name, by default its active
+// version, with TemplateVars as t:variables.
 type Message struct {
-	from          string
-	to            []string
-	cc            []string
-	bcc           []string
-	Subject       string
-	Text          string
-	HTML          string
-	Attachments   map[string]io.Reader
-	Inlines       map[string]io.Reader
-	Tag           string
-	DKIM          *bool // this doesn't seem to have an associated domain API endpoint.
-	DeliveryTime  *time.Time
-	TestMode      *bool
-	Tracking      *bool
-	OpenTracking  *bool
-	ClickTracking TrackOption
-	RequireTLS    *bool
-	SkipVerify    *bool
-	Headers       textproto.MIMEHeader // keys will be canonicalized.
-	Vars          map[string]string
+	from            string
+	to              []string
+	cc              []string
+	bcc             []string
+	Subject         string
+	Text            string
+	HTML            string
+	Attachments     map[string]io.Reader
+	Inlines         map[string]io.Reader
+	Tag             string
+	Tags            []string // further tags, up to MaxTags in all
+	DKIM            *bool    // this doesn't seem to have an associated domain API endpoint.
+	DeliveryTime    *time.Time
+	TestMode        *bool
+	Tracking        *bool
+	OpenTracking    *bool
+	ClickTracking   TrackOption
+	RequireTLS      *bool
+	SkipVerify      *bool
+	Headers         textproto.MIMEHeader // keys will be canonicalized.
+	Vars            map[string]string
+	Template        string
+	TemplateVersion string
+	TemplateVars    map[string]interface{}
+	TemplateText    *bool // also render a text part
 	recipvars
 }
 
 func (m *Message) sum(l []string, s []string) error {
 	sum := len(m.to) + len(m.cc) + len(m.bcc) + len(l) - len(s)
-	if sum > 1000 {
+	if sum > MaxRecipients {
 		return fmt.Errorf("message would have %v receipients", sum)
 	}
 	return nil
@@ -103,11 +111,17 @@ func (m *Message) BCC(bcc ...string) ([]string, error) {
 		return nil, err
 	}
 	was := m.bcc
-	m.cc = make([]string, len(bcc))
+	m.bcc = make([]string, len(bcc))
 	copy(m.bcc, bcc)
 	return was, nil
 }
 
+// Recipients returns copies of the "to", "cc" and "bcc"
+// recipients of Message.
+func (m *Message) Recipients() (to, cc, bcc []string) {
+	return append([]string{}, m.to...), append([]string{}, m.cc...), append([]string{}, m.bcc...)
+}
+
 // Response is a response to a Send request.
 type Response struct {
 	ID string `json:"id"`
@@ -120,60 +134,133 @@ func bs(b bool) string {
 	return `no`
 }
 
+// fields writes form fields, retaining the first error.
+type fields struct {
+	f   func(string, string) error
+	err error
+}
+
+func (w *fields) wf(k, v string) {
+	if w.err == nil {
+		w.err = w.f(k, v)
+	}
+}
+
+func (w *fields) bp(k string, b *bool) {
+	if b != nil {
+		w.wf(k, bs(*b))
+	}
+}
+
+func (w *fields) json(k string, v interface{}) {
+	if w.err != nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		w.err = err
+		return
+	}
+	w.wf(k, string(b))
+}
+
 func (m *Message) textfields(f func(string, string) error) error {
-	var err error
-	wf := func(k, v string) error {
-		if err != nil {
-			return err
-		}
-		err = f(k, v)
-		return err
+	w := &fields{f: f}
+	w.wf("from", m.from)
+	w.wf("subject", m.Subject)
+	if len(m.HTML) > 0 || len(m.Template) == 0 {
+		w.wf("html", m.HTML)
 	}
-	bp := func(name string, b *bool) {
-		if b == nil {
-			return
-		}
-		wf(name, bs(*b))
-	}
-	wf("from", m.from)
-	wf("subject", m.Subject)
-	wf("html", m.HTML)
-	bp("o:dkim", m.DKIM)
-	bp("o:testmode", m.TestMode)
-	bp("o:tracking", m.Tracking)
-	bp("o:tracking-opens", m.OpenTracking)
-	bp("o:require-tls", m.RequireTLS)
-	bp("o:skip-verification", m.SkipVerify)
-	if m.ClickTracking != nil {
-		wf("o:tracking-clicks", fmt.Sprintf(`%s`, m.ClickTracking))
+	if len(m.Template) > 0 {
+		w.wf("template", m.Template)
+	}
+	if len(m.TemplateVersion) > 0 {
+		w.wf("t:version", m.TemplateVersion)
+	}
+	w.bp("t:text", m.TemplateText)
+	if len(m.TemplateVars) > 0 {
+		w.json(`t:variables`, m.TemplateVars)
 	}
 	if len(m.Text) > 0 {
-		wf("text", m.Text)
+		w.wf("text", m.Text)
 	}
+	m.optfields(w)
+	return w.err
+}
+
+// tags returns Tag and Tags.
+func (m *Message) tags() []string {
+	var t []string
 	if len(m.Tag) > 0 {
-		wf("o:tag", m.Tag)
+		t = append(t, m.Tag)
+	}
+	return append(t, m.Tags...)
+}
+
+// optfields writes the fields of m that apply to messages
+// sent by any endpoint.
+func (m *Message) optfields(w *fields) {
+	w.bp("o:dkim", m.DKIM)
+	w.bp("o:testmode", m.TestMode)
+	w.bp("o:tracking", m.Tracking)
+	w.bp("o:tracking-opens", m.OpenTracking)
+	w.bp("o:require-tls", m.RequireTLS)
+	w.bp("o:skip-verification", m.SkipVerify)
+	if m.ClickTracking != nil {
+		w.wf("o:tracking-clicks", fmt.Sprintf(`%s`, m.ClickTracking))
+	}
+	for _, t := range m.tags() {
+		w.wf("o:tag", t)
 	}
 	if m.DeliveryTime != nil {
-		wf("o:deliverytime", m.DeliveryTime.Format(time.RFC1123))
+		w.wf("o:deliverytime", m.DeliveryTime.Format(time.RFC1123))
 	}
 	for k, v := range m.Vars {
 		key := fmt.Sprintf(`v:%s`, k)
-		wf(key, v)
+		w.wf(key, v)
 	}
 	if len(m.recipvars) > 0 {
-		b, err := json.Marshal(m.recipvars)
-		if err != nil {
-			return err
-		}
-		wf(`recipient-variables`, string(b))
+		w.json(`recipient-variables`, m.recipvars)
+	}
+}
+
+// testmoder is a client.Caller that may send in test mode,
+// such as a mailgun.Client.
+type testmoder interface {
+	TestMode() bool
+}
+
+// testing returns m, or a copy of m in test mode if c is a
+// testmoder in test mode and m doesn't set TestMode.
+func (m *Message) testing(c client.Caller) *Message {
+	if t, ok := c.(testmoder); !ok || !t.TestMode() || m.TestMode != nil {
+		return m
 	}
-	return err
+	cp := *m
+	yes := true
+	cp.TestMode = &yes
+	return &cp
 }
 
-// Send sends m, buffering in memory.
+// Send sends m, streaming the request body. Attachments and
+// Inlines are read while the request is in flight, if any of
+// them is not an io.Seeker the request is not retried.
 func (m *Message) Send(c client.Caller) (*Response, error) {
-	buf := new(bytes.Buffer)
-	return m.send(buf, c)
+	parts, replayable, err := m.testing(c).parts()
+	if err != nil {
+		return nil, err
+	}
+	req := c.Post(`messages`).Multipart(parts)
+	if !replayable {
+		req.Retryable(false)
+	}
+	var re *Response
+	return re, req.Decode(&re)
+}
+
+// SendContext sends m using ctx, streaming the request body.
+func (m *Message) SendContext(ctx context.Context, c client.Caller) (*Response, error) {
+	return m.testing(c).Send(client.WithContext(c, ctx))
 }
 
 // TmpSend sends m, buffering to disk.
@@ -184,70 +271,109 @@ func (m *Message) TmpSend(c client.Caller) (*Response, error) {
 	}
 	os.Remove(tf.Name())
 	defer tf.Close()
-	return m.send(tf, c)
-}
-
-func (m *Message) send(rw io.ReadWriter, c client.Caller) (*Response, error) {
-	var err error
-	w := multipart.NewWriter(rw)
-	wf := w.WriteField
-	if err = m.textfields(wf); err != nil {
+	parts, _, err := m.testing(c).parts()
+	if err != nil {
 		return nil, err
 	}
-	for _, t := range m.to {
-		if err = wf(`to`, t); err != nil {
-			return nil, err
-		}
+	w := multipart.NewWriter(tf)
+	if err = parts(w); err != nil {
+		return nil, err
 	}
-	for _, c := range m.cc {
-		if err = wf(`cc`, c); err != nil {
-			return nil, err
-		}
+	if err = w.Close(); err != nil {
+		return nil, err
 	}
-	for _, b := range m.bcc {
-		if err = wf(`bcc`, b); err != nil {
-			return nil, err
-		}
+	if _, err = tf.Seek(0, io.SeekStart); err != nil {
+		return nil, err
 	}
-	for k, v := range m.Headers {
-		key := fmt.Sprintf(`h:%s`, textproto.CanonicalMIMEHeaderKey(k))
-		for _, v := range v {
-			if err = wf(key, v); err != nil {
-				return nil, err
-			}
+	formdata := fmt.Sprintf(`multipart/form-data; boundary=%s`, w.Boundary())
+	req := c.Post(`messages`)
+	req.Header().Set("Content-Type", formdata)
+	var re *Response
+	return re, req.Payload(tf).Decode(&re)
+}
+
+// offsets records the offsets of files that are io.Seekers,
+// returning false if any is not.
+func offsets(files map[string]io.Reader) (map[string]int64, bool, error) {
+	o := make(map[string]int64)
+	replayable := true
+	for k, r := range files {
+		s, ok := r.(io.Seeker)
+		if !ok {
+			replayable = false
+			continue
 		}
-	}
-	for k, r := range m.Attachments {
-		w, err := w.CreateFormFile("attachment", k)
+		off, err := s.Seek(0, io.SeekCurrent)
 		if err != nil {
-			return nil, err
-		}
-		if _, err = io.Copy(w, r); err != nil {
-			return nil, err
+			return nil, false, err
 		}
+		o[k] = off
 	}
-	for k, r := range m.Inlines {
-		w, err := w.CreateFormFile("inline", k)
+	return o, replayable, nil
+}
+
+func writefiles(w *multipart.Writer, field string, files map[string]io.Reader, o map[string]int64) error {
+	for k, r := range files {
+		if off, ok := o[k]; ok {
+			if _, err := r.(io.Seeker).Seek(off, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		fw, err := w.CreateFormFile(field, k)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if _, err = io.Copy(w, r); err != nil {
-			return nil, err
+		if _, err = io.Copy(fw, r); err != nil {
+			return err
 		}
 	}
-	if err = w.Close(); err != nil {
-		return nil, err
+	return nil
+}
+
+// parts returns a function writing the fields and files of m
+// and whether that function may be called more than once.
+func (m *Message) parts() (func(*multipart.Writer) error, bool, error) {
+	aoff, areplay, err := offsets(m.Attachments)
+	if err != nil {
+		return nil, false, err
 	}
-	if s, ok := rw.(io.Seeker); ok {
-		if _, err = s.Seek(0, 0); err != nil {
-			return nil, err
-		}
+	ioff, ireplay, err := offsets(m.Inlines)
+	if err != nil {
+		return nil, false, err
 	}
-	formdata := fmt.Sprintf(`multipart/form-data; boundary=%s`, w.Boundary())
-	req := c.Post(`messages`)
-	req.Header().Set("Content-Type", formdata)
-	var re *Response
-	return re, req.Payload(rw).Decode(&re)
+	return func(w *multipart.Writer) error {
+		wf := w.WriteField
+		if err := m.textfields(wf); err != nil {
+			return err
+		}
+		for _, t := range m.to {
+			if err := wf(`to`, t); err != nil {
+				return err
+			}
+		}
+		for _, c := range m.cc {
+			if err := wf(`cc`, c); err != nil {
+				return err
+			}
+		}
+		for _, b := range m.bcc {
+			if err := wf(`bcc`, b); err != nil {
+				return err
+			}
+		}
+		for k, v := range m.Headers {
+			key := fmt.Sprintf(`h:%s`, textproto.CanonicalMIMEHeaderKey(k))
+			for _, v := range v {
+				if err := wf(key, v); err != nil {
+					return err
+				}
+			}
+		}
+		if err := writefiles(w, `attachment`, m.Attachments, aoff); err != nil {
+			return err
+		}
+		return writefiles(w, `inline`, m.Inlines, ioff)
+	}, areplay && ireplay, nil
 }
 
 // New returns a *Message with from, subject, and html content.
@@ -261,6 +387,18 @@ func New(from, subject, html string, to ...string) (*Message, error) {
 	return &Message{from: from, Subject: subject, HTML: html, to: to, Attachments: make(map[string]io.Reader), Inlines: make(map[string]io.Reader), Headers: make(textproto.MIMEHeader), Vars: make(map[string]string)}, nil
 }
 
+// NewTemplate returns a *Message like New with the body
+// rendered from the stored template of that name.
+func NewTemplate(from, subject, template string, to ...string) (*Message, error) {
+	m, err := New(from, subject, ``, to...)
+	if err != nil {
+		return nil, err
+	}
+	m.Template = template
+	m.TemplateVars = make(map[string]interface{})
+	return m, nil
+}
+
 // BUG(j7b): Message.Vars is a little problematic. The relevant
 // documentation at https://documentation.mailgun.com/en/latest/user_manual.html#attaching-data-to-messages
 // is at best unclear. Empirically JSON-formatted strings
@@ -1,8 +1,23 @@
 package message
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/capture"
 	"github.com/j7b/mailgun/client/mock"
 )
 
@@ -43,3 +58,283 @@ func TestSend(t *testing.T) {
 		}
 	}
 }
+
+func TestSendStream(t *testing.T) {
+	var form *multipart.Form
+	var length int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		length = r.ContentLength
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+		}
+		form = r.MultipartForm
+		w.Write([]byte(`{"id": "<id@domain.test>"}`))
+	}))
+	defer s.Close()
+	c := client.New(s.URL+`/v3/`, `key`, `domain.test`)
+	msg, err := New(`a@domain.test`, `subject`, `<b>html</b>`, `b@domain.test`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte(`attached`))
+		pw.Close()
+	}()
+	msg.Attachments[`a.txt`] = pr
+	res, err := msg.Send(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ID != `<id@domain.test>` {
+		t.Fatal(`unexpected id`, res.ID)
+	}
+	if length != -1 {
+		t.Fatal(`want streamed body got Content-Length`, length)
+	}
+	if form.Value[`subject`][0] != `subject` || form.Value[`to`][0] != `b@domain.test` {
+		t.Fatal(`unexpected fields`, form.Value)
+	}
+	f, err := form.File[`attachment`][0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(f); string(b) != `attached` {
+		t.Fatal(`unexpected attachment`, string(b))
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	c := capture.New(t)
+	m, err := NewTemplate(`a@domain.fake`, `Welcome`, `welcome`, `b@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.TemplateVersion = `v2`
+	m.TemplateVars[`name`] = `Bee`
+	yes := true
+	m.TemplateText = &yes
+	if _, err = m.Send(c); err != nil {
+		t.Fatal(err)
+	}
+	f := c.LastMessage().Form
+	if f.Get(`template`) != `welcome` || f.Get(`t:version`) != `v2` || f.Get(`t:text`) != `yes` || f.Get(`t:variables`) != `{"name":"Bee"}` {
+		t.Fatal(`want template fields got`, f)
+	}
+	if _, ok := f[`html`]; ok {
+		t.Fatal(`want no html got`, f[`html`])
+	}
+}
+
+func TestSendMIME(t *testing.T) {
+	c := capture.New(t)
+	raw := "From: a@domain.fake\r\nTo: b@example.com\r\nCc: c@example.com\r\nBcc: d@example.com\r\nSubject: Raw\r\n\r\nhello\r\n"
+	m, err := NewMIME(`b@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Tag = `raw`
+	yes := true
+	m.TestMode = &yes
+	if _, err = m.SendMIME(c, strings.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+	sent := c.Server().Messages()[0]
+	if sent.Subject != `Raw` || string(sent.MIME) != raw || sent.Form.Get(`o:tag`) != `raw` || sent.Form.Get(`o:testmode`) != `yes` {
+		t.Fatal(`want raw message with options got`, sent)
+	}
+	signed := "DKIM-Signature: v=1; h=from:to:subject;\r\n b=abc\r\nSubject: Raw\r\nFrom: a@domain.fake\r\nBcc: d@example.com,\r\n e@example.com\r\nTo: b@example.com\r\ncc: c@example.com\r\nReceived: by x\r\nReceived: by y\r\n\r\nhello\r\n"
+	if m, err = NewMIME(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.SendRaw(c, strings.NewReader(signed)); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(signed, "Bcc: d@example.com,\r\n e@example.com\r\n", ``, 1)
+	if b := string(c.LastMessage().Files[0].Data); b != want {
+		t.Fatal(`want header in order without Bcc got`, b)
+	}
+	last := c.LastMessage()
+	if to := last.Form[`to`]; len(to) != 4 || to[3] != `e@example.com` {
+		t.Fatal(`want header recipients got`, to)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.SendMail(c, msg); err != nil {
+		t.Fatal(err)
+	}
+	last = c.LastMessage()
+	if to := last.Form[`to`]; len(to) != 3 || to[2] != `d@example.com` {
+		t.Fatal(`want header recipients got`, to)
+	}
+	if b := last.Files[0].Data; bytes.Contains(b, []byte(`Bcc`)) || !bytes.HasSuffix(b, []byte("\r\n\r\nhello\r\n")) {
+		t.Fatal(`want message without Bcc got`, string(b))
+	}
+	if _, err = m.SendRaw(c, strings.NewReader("From: a@domain.fake\r\nTo: b@example.com\r\nSubject: Empty")); err != nil {
+		t.Fatal(err)
+	}
+	if b := string(c.LastMessage().Files[0].Data); !strings.HasSuffix(b, "Subject: Empty\r\n\r\n") {
+		t.Fatal(`want headers-only message got`, b)
+	}
+}
+
+func TestWriteMIME(t *testing.T) {
+	m, err := New(`Ann <a@domain.fake>`, `Grüße`, `<img src="cid:logo.png">`, `b@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Text = `hello`
+	m.BCC(`hidden@example.com`)
+	m.Headers.Set(`x-custom`, `1`)
+	m.Inlines[`logo.png`] = strings.NewReader(`png`)
+	m.Attachments[`report.txt`] = strings.NewReader(strings.Repeat(`report `, 20))
+	b, err := m.MIME()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get(`Subject`)); s != `Grüße` || msg.Header.Get(`X-Custom`) != `1` || len(msg.Header.Get(`Bcc`)) > 0 {
+		t.Fatal(`want headers got`, msg.Header)
+	}
+	var tree []string
+	var walk func(ctype string, body io.Reader, depth int)
+	walk = func(ctype string, body io.Reader, depth int) {
+		mt, params, err := mime.ParseMediaType(ctype)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree = append(tree, strings.Repeat(` `, depth)+mt)
+		if !strings.HasPrefix(mt, `multipart/`) {
+			return
+		}
+		mr := multipart.NewReader(body, params[`boundary`])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.FileName() == `report.txt` {
+				data, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+				if string(data) != strings.Repeat(`report `, 20) {
+					t.Fatal(`want attachment got`, string(data))
+				}
+			}
+			walk(p.Header.Get(`Content-Type`), p, depth+1)
+		}
+	}
+	walk(msg.Header.Get(`Content-Type`), msg.Body, 0)
+	m.Headers.Set(`subject`, `other`)
+	if _, err = m.MIME(); err == nil || m.Validate() == nil {
+		t.Fatal(`want reserved header rejected by MIME and Validate`)
+	}
+	want := []string{`multipart/mixed`, ` multipart/related`, `  multipart/alternative`, `   text/plain`, `   text/html`, `  image/png`, ` text/plain`}
+	if strings.Join(tree, `,`) != strings.Join(want, `,`) {
+		t.Fatal(`want`, want, `got`, tree)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	msg, err := New(`Mailgun <postmaster@sandbox.mailgun.org>`, `Hey bud!`, `awesome`, `rick@roll.net`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = msg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	msg.To(`rick@roll.net`, `not an address`)
+	msg.HTML = ``
+	msg.Attachments[`big.bin`] = bytes.NewReader(make([]byte, MaxSize))
+	msg.Headers[`subject`] = []string{`other`}
+	msg.Headers[`X-Custom`] = []string{`a`, `b`}
+	msg.Headers[`X-Mailgun-Custom`] = []string{`ok`}
+	msg.Headers[`x-custom`] = []string{`c`}
+	msg.Headers[`X-Mailgun-Variables`] = []string{`{}`}
+	msg.Tag = `one`
+	msg.Tags = []string{`two`, `three`, strings.Repeat(`x`, MaxTagLength+1)}
+	when := time.Now().Add(MaxSchedule + time.Hour)
+	msg.DeliveryTime = &when
+	msg.SetRecipVars(map[string]map[string]interface{}{`Rick <rick@roll.net>`: nil})
+	err = msg.Validate()
+	var v ValidationError
+	if !errors.As(err, &v) {
+		t.Fatalf("want ValidationError got %v", err)
+	}
+	for _, want := range []string{
+		`to "not an address"`,
+		`no text, html or template`,
+		`size`,
+		`header subject is reserved`,
+		`header x-custom duplicates X-Custom`,
+		`header X-Mailgun-Variables is reserved`,
+		`4 tags`,
+		`tag of 129 bytes`,
+		`delivery time`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("want %q in %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), `X-Mailgun-Custom`) || strings.Contains(err.Error(), `values`) {
+		t.Error(`custom header flagged:`, err)
+	}
+	if strings.Contains(err.Error(), `no recipient-variables`) {
+		t.Error(`recipient-variables keyed by RFC 5322 address not matched:`, err)
+	}
+	if off, _ := msg.Attachments[`big.bin`].(io.Seeker).Seek(0, io.SeekCurrent); off != 0 {
+		t.Error(`attachment offset want 0 got`, off)
+	}
+	msg.SetRecipVars(map[string]map[string]interface{}{`other@roll.net`: nil})
+	if err = msg.Validate(); !strings.Contains(err.Error(), `to "rick@roll.net": no recipient-variables`) {
+		t.Error(`want recipient-variables problem got`, err)
+	}
+}
+
+func TestWriteMIMEFold(t *testing.T) {
+	to := make([]string, 1000)
+	for i := range to {
+		to[i] = fmt.Sprintf(`Recipient %d <r%d@example.com>`, i, i)
+	}
+	m, err := New(`Ann <a@domain.fake>`, strings.Repeat(`Grüße `, 40), `hi`, to...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		m.Vars[fmt.Sprintf(`key%d`, i)] = `a value, with a comma`
+	}
+	b, err := m.MIME()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := b[:bytes.Index(b, []byte("\r\n\r\n"))]
+	for _, l := range strings.Split(string(head), "\r\n") {
+		if len(l) > 998 {
+			t.Fatal(`line of`, len(l), `characters`)
+		}
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := msg.Header.AddressList(`To`)
+	if err != nil || len(addrs) != 1000 || addrs[999].Address != `r999@example.com` {
+		t.Fatal(`want 1000 addresses got`, len(addrs), err)
+	}
+	if s, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get(`Subject`)); s != m.Subject {
+		t.Fatal(`want subject got`, s)
+	}
+	var vars map[string]string
+	if err = json.Unmarshal([]byte(msg.Header.Get(`X-Mailgun-Variables`)), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) != 200 || vars[`key199`] != `a value, with a comma` {
+		t.Fatal(`want vars got`, vars)
+	}
+}
@@ -0,0 +1,241 @@
+package message
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"sort"
+
+	"github.com/j7b/mailgun/client"
+)
+
+// NewMIME returns a *Message for sending a MIME message with
+// SendMIME, SendRaw or SendMail to recipients to.
+func NewMIME(to ...string) (*Message, error) {
+	return New(``, ``, ``, to...)
+}
+
+// recipients returns every recipient of m.
+func (m *Message) recipients() []string {
+	r := append([]string{}, m.to...)
+	r = append(r, m.cc...)
+	return append(r, m.bcc...)
+}
+
+// SendMIME sends the RFC 5322 message read from raw to the to,
+// cc and bcc recipients of m through the messages.mime endpoint,
+// with the tracking, tag, test mode, delivery time and variable
+// options of m. The from, subject, content, template, headers,
+// attachments and inlines of m are not sent, raw includes them.
+// If raw is not an io.Seeker the request is not retried.
+func (m *Message) SendMIME(c client.Caller, raw io.Reader) (*Response, error) {
+	offset := int64(-1)
+	if s, ok := raw.(io.Seeker); ok {
+		off, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		offset = off
+	}
+	return m.sendmime(c, m.recipients(), func() (io.Reader, error) {
+		if offset >= 0 {
+			if _, err := raw.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		return raw, nil
+	}, offset >= 0)
+}
+
+// header reads the header of a message from br, returning
+// it with the blank line ending it, as read. A message that
+// ends with its header has a blank line added.
+func header(br *bufio.Reader) ([]byte, error) {
+	head := new(bytes.Buffer)
+	for {
+		line, err := br.ReadBytes('\n')
+		head.Write(line)
+		if err == io.EOF && head.Len() > 0 {
+			if len(line) > 0 {
+				head.WriteString("\r\n")
+			}
+			head.WriteString("\r\n")
+			return head.Bytes(), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sendmail: header: %w", err)
+		}
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			return head.Bytes(), nil
+		}
+	}
+}
+
+// stripbcc returns head without Bcc fields, every other
+// field as it was.
+func stripbcc(head []byte) []byte {
+	out := make([]byte, 0, len(head))
+	skip := false
+	for _, line := range bytes.SplitAfter(head, []byte("\n")) {
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			if !skip {
+				out = append(out, line...)
+			}
+			continue
+		}
+		i := bytes.IndexByte(line, ':')
+		skip = i > 0 && textproto.CanonicalMIMEHeaderKey(string(bytes.TrimSpace(line[:i]))) == `Bcc`
+		if !skip {
+			out = append(out, line...)
+		}
+	}
+	return out
+}
+
+// SendRaw sends the RFC 5322 message read from raw like
+// SendMIME, without any Bcc header field. The header is
+// otherwise sent as read, in order, so signatures such as
+// DKIM remain valid. If m has no recipients, the message is
+// sent to the addresses of its To, Cc and Bcc fields. If raw
+// is not an io.Seeker the request is not retried.
+func (m *Message) SendRaw(c client.Caller, raw io.Reader) (*Response, error) {
+	offset, err := seekoffset(raw)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(raw)
+	head, err := header(br)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendraw(c, head, func(first bool) (io.Reader, error) {
+		if !first {
+			if _, err := raw.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			br = bufio.NewReader(raw)
+			if _, err := header(br); err != nil {
+				return nil, err
+			}
+		}
+		return br, nil
+	}, offset >= 0)
+}
+
+// SendMail sends msg like SendRaw. The header of msg is
+// written in sorted order, as a mail.Header doesn't keep the
+// order it was read in; use SendRaw for messages with
+// signatures over the header. If msg.Body is not an
+// io.Seeker the request is not retried.
+func (m *Message) SendMail(c client.Caller, msg *mail.Message) (*Response, error) {
+	offset, err := seekoffset(msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	head := new(bytes.Buffer)
+	keys := make([]string, 0, len(msg.Header))
+	for k := range msg.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range msg.Header[k] {
+			fmt.Fprintf(head, "%s: %s\r\n", k, v)
+		}
+	}
+	head.WriteString("\r\n")
+	return m.sendraw(c, head.Bytes(), func(first bool) (io.Reader, error) {
+		if !first {
+			if _, err := msg.Body.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		return msg.Body, nil
+	}, offset >= 0)
+}
+
+// seekoffset returns the offset of r, -1 if it isn't an
+// io.Seeker.
+func seekoffset(r io.Reader) (int64, error) {
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return -1, nil
+	}
+	return s.Seek(0, io.SeekCurrent)
+}
+
+// sendraw sends the message with header head and the body
+// returned by body, true on the first attempt, to the
+// recipients of m or else of head, without Bcc fields.
+func (m *Message) sendraw(c client.Caller, head []byte, body func(first bool) (io.Reader, error), replayable bool) (*Response, error) {
+	rcpts := m.recipients()
+	if len(rcpts) == 0 {
+		msg, err := mail.ReadMessage(bytes.NewReader(head))
+		if err != nil {
+			return nil, fmt.Errorf("sendmail: %w", err)
+		}
+		for _, k := range []string{`To`, `Cc`, `Bcc`} {
+			if len(msg.Header.Get(k)) == 0 {
+				continue
+			}
+			addrs, err := msg.Header.AddressList(k)
+			if err != nil {
+				return nil, fmt.Errorf("sendmail: %s: %w", k, err)
+			}
+			for _, a := range addrs {
+				rcpts = append(rcpts, a.Address)
+			}
+		}
+	}
+	head = stripbcc(head)
+	first := true
+	return m.sendmime(c, rcpts, func() (io.Reader, error) {
+		b, err := body(first)
+		if err != nil {
+			return nil, err
+		}
+		first = false
+		return io.MultiReader(bytes.NewReader(head), b), nil
+	}, replayable)
+}
+
+func (m *Message) sendmime(c client.Caller, rcpts []string, open func() (io.Reader, error), replayable bool) (*Response, error) {
+	m = m.testing(c)
+	if len(rcpts) == 0 {
+		return nil, fmt.Errorf("sendmime: no recipients")
+	}
+	if len(rcpts) > 1000 {
+		return nil, fmt.Errorf("sendmime: %v recipients", len(rcpts))
+	}
+	req := c.Post(`messages.mime`).Multipart(func(w *multipart.Writer) error {
+		for _, r := range rcpts {
+			if err := w.WriteField(`to`, r); err != nil {
+				return err
+			}
+		}
+		f := &fields{f: w.WriteField}
+		m.optfields(f)
+		if f.err != nil {
+			return f.err
+		}
+		raw, err := open()
+		if err != nil {
+			return err
+		}
+		fw, err := w.CreateFormFile(`message`, `message.mime`)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, raw)
+		return err
+	})
+	if !replayable {
+		req.Retryable(false)
+	}
+	var re *Response
+	return re, req.Decode(&re)
+}
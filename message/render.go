@@ -0,0 +1,338 @@
+package message
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// entity is a MIME entity, a leaf with a body or a
+// multipart entity with parts.
+type entity struct {
+	h     textproto.MIMEHeader
+	body  func(io.Writer) error
+	parts []*entity
+}
+
+// multi returns a multipart entity of subtype with parts,
+// or the only part.
+func multi(subtype string, parts ...*entity) *entity {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	b := multipart.NewWriter(io.Discard).Boundary()
+	h := make(textproto.MIMEHeader)
+	h.Set(`Content-Type`, mime.FormatMediaType(`multipart/`+subtype, map[string]string{`boundary`: b}))
+	return &entity{h: h, parts: parts}
+}
+
+func (e *entity) write(w io.Writer) error {
+	if e.parts == nil {
+		return e.body(w)
+	}
+	_, params, err := mime.ParseMediaType(e.h.Get(`Content-Type`))
+	if err != nil {
+		return err
+	}
+	mw := multipart.NewWriter(w)
+	if err = mw.SetBoundary(params[`boundary`]); err != nil {
+		return err
+	}
+	for _, p := range e.parts {
+		pw, err := mw.CreatePart(p.h)
+		if err != nil {
+			return err
+		}
+		if err = p.write(pw); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func text(subtype, s string) *entity {
+	h := make(textproto.MIMEHeader)
+	h.Set(`Content-Type`, `text/`+subtype+`; charset=utf-8`)
+	h.Set(`Content-Transfer-Encoding`, `quoted-printable`)
+	return &entity{h: h, body: func(w io.Writer) error {
+		qw := quotedprintable.NewWriter(w)
+		if _, err := io.WriteString(qw, s); err != nil {
+			return err
+		}
+		return qw.Close()
+	}}
+}
+
+// lines breaks base64 output into lines of 76 characters.
+type lines struct {
+	w io.Writer
+	n int
+}
+
+func (l *lines) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if rest := 76 - l.n; len(chunk) > rest {
+			chunk = chunk[:rest]
+		}
+		n, err := l.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+		if l.n += n; l.n == 76 {
+			if _, err = io.WriteString(l.w, "\r\n"); err != nil {
+				return written, err
+			}
+			l.n = 0
+		}
+	}
+	return written, nil
+}
+
+func file(disposition, name string, r io.Reader, offset int64, ok bool) *entity {
+	ctype := mime.TypeByExtension(filepath.Ext(name))
+	if len(ctype) == 0 {
+		ctype = `application/octet-stream`
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set(`Content-Type`, ctype)
+	h.Set(`Content-Transfer-Encoding`, `base64`)
+	h.Set(`Content-Disposition`, mime.FormatMediaType(disposition, map[string]string{`filename`: name}))
+	if disposition == `inline` {
+		h.Set(`Content-Id`, `<`+name+`>`)
+	}
+	return &entity{h: h, body: func(w io.Writer) error {
+		if ok {
+			if _, err := r.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		l := &lines{w: w}
+		enc := base64.NewEncoder(base64.StdEncoding, l)
+		if _, err := io.Copy(enc, r); err != nil {
+			return err
+		}
+		return enc.Close()
+	}}
+}
+
+func files(disposition string, m map[string]io.Reader, o map[string]int64) []*entity {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var es []*entity
+	for _, k := range names {
+		off, ok := o[k]
+		es = append(es, file(disposition, k, m[k], off, ok))
+	}
+	return es
+}
+
+// addresses formats a list of addresses for a header.
+func addresses(l []string) string {
+	f := make([]string, len(l))
+	for i, s := range l {
+		f[i] = s
+		if a, err := mail.ParseAddress(s); err == nil {
+			f[i] = a.String()
+		}
+	}
+	return strings.Join(f, `, `)
+}
+
+func messageid(from string) string {
+	host := `localhost`
+	if a, err := mail.ParseAddress(from); err == nil {
+		host = a.Address[strings.LastIndexByte(a.Address, '@')+1:]
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf(`<%x.%x@%s>`, time.Now().UnixNano(), b, host)
+}
+
+// spaced returns v as JSON with spaces between elements,
+// where header fields can be folded.
+func spaced(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ``, err
+	}
+	buf := new(bytes.Buffer)
+	if err = json.Indent(buf, b, ``, ` `); err != nil {
+		return ``, err
+	}
+	return strings.ReplaceAll(buf.String(), "\n", ``), nil
+}
+
+// header returns the message header of m. Headers of m
+// replace those generated, such as Date and Message-Id,
+// but may not set those reserved, as with Validate.
+func (m *Message) header() (textproto.MIMEHeader, error) {
+	h := make(textproto.MIMEHeader)
+	set := func(k, v string) {
+		if len(v) > 0 {
+			h.Set(k, v)
+		}
+	}
+	set(`From`, addresses([]string{m.from}))
+	set(`To`, addresses(m.to))
+	set(`Cc`, addresses(m.cc))
+	set(`Subject`, mime.QEncoding.Encode(`utf-8`, m.Subject))
+	set(`Date`, time.Now().Format(time.RFC1123Z))
+	set(`Message-Id`, messageid(m.from))
+	set(`Mime-Version`, `1.0`)
+	for _, t := range m.tags() {
+		h.Add(`X-Mailgun-Tag`, t)
+	}
+	if len(m.Vars) > 0 {
+		b, err := spaced(m.Vars)
+		if err != nil {
+			return nil, err
+		}
+		set(`X-Mailgun-Variables`, b)
+	}
+	set(`X-Mailgun-Template-Name`, m.Template)
+	set(`X-Mailgun-Template-Version`, m.TemplateVersion)
+	if len(m.TemplateVars) > 0 {
+		b, err := spaced(m.TemplateVars)
+		if err != nil {
+			return nil, err
+		}
+		set(`X-Mailgun-Template-Variables`, b)
+	}
+	for k, v := range m.Headers {
+		k = textproto.CanonicalMIMEHeaderKey(k)
+		if reserved[k] {
+			return nil, fmt.Errorf("render: header %s is reserved", k)
+		}
+		h[k] = v
+	}
+	return h, nil
+}
+
+// leading are header fields written first, in order.
+var leading = []string{`From`, `To`, `Cc`, `Subject`, `Date`, `Message-Id`, `Mime-Version`}
+
+// fold writes the header field k: v, folded at spaces into
+// lines of 78 characters where it can be.
+func fold(w *bufio.Writer, k, v string) error {
+	var lines []string
+	line := k + `:`
+	for i, word := range strings.Split(v, ` `) {
+		if i > 0 && len(line)+1+len(word) > 78 {
+			lines = append(lines, line)
+			line = ``
+		}
+		line += ` ` + word
+	}
+	lines = append(lines, line)
+	for _, l := range lines {
+		if len(l) > 998 {
+			return fmt.Errorf("render: header %s has a line longer than 998 characters", k)
+		}
+	}
+	_, err := w.WriteString(strings.Join(lines, "\r\n") + "\r\n")
+	return err
+}
+
+func writeheader(w *bufio.Writer, h textproto.MIMEHeader) error {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rank := func(k string) int {
+		for i, l := range leading {
+			if k == l {
+				return i
+			}
+		}
+		return len(leading)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return rank(keys[i]) < rank(keys[j]) })
+	for _, k := range keys {
+		for _, v := range h[k] {
+			if strings.ContainsAny(v, "\r\n") {
+				return fmt.Errorf("render: header %s contains a line break", k)
+			}
+			if err := fold(w, k, v); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := w.WriteString("\r\n")
+	return err
+}
+
+// WriteMIME renders m as an RFC 5322 message to w: Text and
+// HTML as multipart/alternative parts, with Inlines, which
+// have their names as Content-IDs, as multipart/related parts
+// and Attachments as multipart/mixed parts. Headers of m are
+// included and replace those generated, except those reserved
+// (see Validate), which fail. Bcc recipients are not included.
+// Stored templates are not rendered, their name, version and
+// variables are included as X-Mailgun headers for relaying
+// through Mailgun SMTP, as are tags and Vars. Attachments and
+// Inlines that aren't io.Seekers are read to the end, so m
+// can't be rendered or sent again with them.
+func (m *Message) WriteMIME(w io.Writer) error {
+	aoff, _, err := offsets(m.Attachments)
+	if err != nil {
+		return err
+	}
+	ioff, _, err := offsets(m.Inlines)
+	if err != nil {
+		return err
+	}
+	h, err := m.header()
+	if err != nil {
+		return err
+	}
+	var alt []*entity
+	if len(m.Text) > 0 || len(m.HTML) == 0 {
+		alt = append(alt, text(`plain`, m.Text))
+	}
+	if len(m.HTML) > 0 {
+		alt = append(alt, text(`html`, m.HTML))
+	}
+	body := multi(`related`, append([]*entity{multi(`alternative`, alt...)}, files(`inline`, m.Inlines, ioff)...)...)
+	body = multi(`mixed`, append([]*entity{body}, files(`attachment`, m.Attachments, aoff)...)...)
+	for k, v := range body.h {
+		h[k] = v
+	}
+	bw := bufio.NewWriter(w)
+	if err = writeheader(bw, h); err != nil {
+		return err
+	}
+	if err = body.write(bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// MIME returns m rendered by WriteMIME.
+func (m *Message) MIME() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := m.WriteMIME(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,179 @@
+package message
+
+import (
+	"fmt"
+	"io"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limits of the messages endpoint.
+const (
+	MaxRecipients = 1000
+	MaxSize       = 25 << 20 // bytes, content and files
+	MaxTags       = 3
+	MaxTagLength  = 128
+	MaxSchedule   = 72 * time.Hour // latest DeliveryTime from now
+)
+
+// ValidationError is the problems found by Validate.
+type ValidationError []error
+
+func (v ValidationError) Error() string {
+	s := make([]string, len(v))
+	for i, err := range v {
+		s[i] = err.Error()
+	}
+	return `message: ` + strings.Join(s, `; `)
+}
+
+// Unwrap returns the problems of v.
+func (v ValidationError) Unwrap() []error {
+	return v
+}
+
+// reserved are header fields this package sets from other
+// fields of Message, which Headers may not set: Validate
+// reports them and WriteMIME fails.
+var reserved = map[string]bool{
+	`From`:                         true,
+	`To`:                           true,
+	`Cc`:                           true,
+	`Bcc`:                          true,
+	`Subject`:                      true,
+	`Content-Type`:                 true,
+	`Content-Transfer-Encoding`:    true,
+	`Mime-Version`:                 true,
+	`X-Mailgun-Tag`:                true,
+	`X-Mailgun-Variables`:          true,
+	`X-Mailgun-Template-Name`:      true,
+	`X-Mailgun-Template-Version`:   true,
+	`X-Mailgun-Template-Variables`: true,
+}
+
+// size returns the bytes remaining in the files that are
+// io.Seekers, restoring their offsets.
+func size(files map[string]io.Reader) (int64, error) {
+	var n int64
+	for _, r := range files {
+		s, ok := r.(io.Seeker)
+		if !ok {
+			continue
+		}
+		off, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, err
+		}
+		end, err := s.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, err
+		}
+		if _, err = s.Seek(off, io.SeekStart); err != nil {
+			return 0, err
+		}
+		n += end - off
+	}
+	return n, nil
+}
+
+// Validate returns a ValidationError with every problem
+// that would have the messages endpoint reject m, nil if
+// none is found. The size of Attachments and Inlines that
+// aren't io.Seekers isn't known and not counted.
+func (m *Message) Validate() error {
+	var v ValidationError
+	add := func(format string, a ...interface{}) {
+		v = append(v, fmt.Errorf(format, a...))
+	}
+	if _, err := mail.ParseAddress(m.from); err != nil {
+		add("from %q: %v", m.from, err)
+	}
+	covered := make(map[string]bool)
+	for k := range m.recipvars {
+		if a, err := mail.ParseAddress(k); err == nil {
+			k = a.Address
+		}
+		covered[strings.ToLower(k)] = true
+	}
+	for _, l := range []struct {
+		field string
+		addrs []string
+	}{{`to`, m.to}, {`cc`, m.cc}, {`bcc`, m.bcc}} {
+		for _, s := range l.addrs {
+			a, err := mail.ParseAddress(s)
+			if err != nil {
+				add("%s %q: %v", l.field, s, err)
+				continue
+			}
+			if l.field == `to` && len(m.recipvars) > 0 && !covered[strings.ToLower(a.Address)] {
+				add("to %q: no recipient-variables", s)
+			}
+		}
+	}
+	switch n := len(m.recipients()); {
+	case n == 0:
+		add("no recipients")
+	case n > MaxRecipients:
+		add("%d recipients, limit %d", n, MaxRecipients)
+	}
+	if len(m.Text) == 0 && len(m.HTML) == 0 && len(m.Template) == 0 {
+		add("no text, html or template")
+	}
+	total := int64(len(m.Subject) + len(m.Text) + len(m.HTML))
+	for _, files := range []map[string]io.Reader{m.Attachments, m.Inlines} {
+		n, err := size(files)
+		if err != nil {
+			add("size: %v", err)
+		}
+		total += n
+	}
+	if total > MaxSize {
+		add("size %d bytes, limit %d", total, MaxSize)
+	}
+	names := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	canonical := make(map[string]string)
+	for _, k := range names {
+		c := textproto.CanonicalMIMEHeaderKey(k)
+		if reserved[c] {
+			add("header %s is reserved", k)
+		}
+		if prev, ok := canonical[c]; ok {
+			add("header %s duplicates %s", k, prev)
+		}
+		canonical[c] = k
+		for _, s := range m.Headers[k] {
+			if strings.ContainsAny(s, "\r\n") {
+				add("header %s contains a line break", k)
+			}
+		}
+	}
+	tags := m.tags()
+	if len(tags) > MaxTags {
+		add("%d tags, limit %d", len(tags), MaxTags)
+	}
+	for _, t := range tags {
+		if len(t) > MaxTagLength {
+			add("tag of %d bytes, limit %d", len(t), MaxTagLength)
+		}
+	}
+	if m.DeliveryTime != nil {
+		now := time.Now()
+		switch d := *m.DeliveryTime; {
+		case d.Before(now.Add(-time.Minute)):
+			add("delivery time %s is past", d.Format(time.RFC1123))
+		case d.After(now.Add(MaxSchedule)):
+			add("delivery time %s is more than %s ahead", d.Format(time.RFC1123), MaxSchedule)
+		}
+	}
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
@@ -3,8 +3,10 @@ package bounce
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/j7b/mailgun/client"
@@ -50,6 +52,12 @@ func (b *List) Last() (*List, error) {
 	return b.pager(b.Pager.Paging.Last)
 }
 
+// All returns an iterator over the bounces of b
+// and subsequent pages.
+func (b *List) All() iter.Seq2[Bounce, error] {
+	return pager.Seq(b.Bounces, b.Paging)
+}
+
 // API implements the bounce API.
 type API struct {
 	c client.Caller
@@ -66,6 +74,11 @@ func Bounces(c client.Caller) *API {
 	return &API{c: c}
 }
 
+// WithContext returns a copy of a whose requests use ctx.
+func (a *API) WithContext(ctx context.Context) *API {
+	return &API{c: client.WithContext(a.c, ctx)}
+}
+
 // Get retrieves a bounce by address.
 func (a *API) Get(address string) (*Bounce, error) {
 	var b *Bounce
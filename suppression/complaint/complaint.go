@@ -3,7 +3,9 @@ package complaint
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"iter"
 	"time"
 
 	"github.com/j7b/mailgun/client"
@@ -47,6 +49,12 @@ func (c *List) Last() (*List, error) {
 	return c.pager(c.Pager.Paging.Last)
 }
 
+// All returns an iterator over the complaints of c
+// and subsequent pages.
+func (c *List) All() iter.Seq2[Complaint, error] {
+	return pager.Seq(c.Complaints, c.Paging)
+}
+
 // API to complaints.
 type API struct {
 	c client.Caller
@@ -57,6 +65,11 @@ func Complaints(c client.Caller) *API {
 	return &API{c: c}
 }
 
+// WithContext returns a copy of a whose requests use ctx.
+func (a *API) WithContext(ctx context.Context) *API {
+	return &API{c: client.WithContext(a.c, ctx)}
+}
+
 // List complaints.
 func (a *API) List() (*List, error) {
 	var l *List
@@ -3,7 +3,9 @@ package unsubscribe
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"iter"
 	"time"
 
 	"github.com/j7b/mailgun/client"
@@ -48,6 +50,12 @@ func (u *List) Last() (*List, error) {
 	return u.pager(u.Pager.Paging.Last)
 }
 
+// All returns an iterator over the unsubscribes of u
+// and subsequent pages.
+func (u *List) All() iter.Seq2[Unsubscribe, error] {
+	return pager.Seq(u.Unsubscribes, u.Paging)
+}
+
 // API implements the unsubscribe API.
 type API struct {
 	c client.Caller
@@ -58,6 +66,11 @@ func Unsubscribes(c client.Caller) *API {
 	return &API{c: c}
 }
 
+// WithContext returns a copy of a whose requests use ctx.
+func (a *API) WithContext(ctx context.Context) *API {
+	return &API{c: client.WithContext(a.c, ctx)}
+}
+
 // List returns a list of unsubscribes.
 func (a *API) List() (*List, error) {
 	var ul *List
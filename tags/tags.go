@@ -4,6 +4,7 @@ package tags
 
 import (
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/j7b/mailgun/client"
@@ -47,6 +48,12 @@ func (t *Tags) Last() (*Tags, error) {
 	return tags, t.Paging.Last(&tags)
 }
 
+// All returns an iterator over the tags of t
+// and subsequent pages.
+func (t *Tags) All() iter.Seq2[Tag, error] {
+	return pager.Seq(t.Tags, t.Paging)
+}
+
 // Delete deletes tag by name.
 func Delete(c client.Caller, name string) error {
 	return c.Delete(`tags`, name).Err()
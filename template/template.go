@@ -0,0 +1,229 @@
+// Package template implements stored templates and
+// their versions.
+/*
+Messages are sent with a stored template by setting the
+Template fields of message.Message, see message.NewTemplate.
+*/
+package template
+
+import (
+	"iter"
+
+	"github.com/j7b/mailgun/client"
+	"github.com/j7b/mailgun/client/pager"
+)
+
+// Template is a stored template. Version is the active or
+// requested version, if the template was retrieved with it.
+type Template struct {
+	Name        string   `json:"name"`        // "name": "template.name",
+	Description string   `json:"description"` // "description": "template description",
+	CreatedAt   string   `json:"createdAt"`   // "createdAt": "Wed, 29 Aug 2018 23:31:11 UTC",
+	CreatedBy   string   `json:"createdBy"`   // "createdBy": "user-supplied-value",
+	ID          string   `json:"id"`          // "id": "46565d87-68b6-4edb-8b3c-34554af4bb77",
+	Version     *Version `json:"version,omitempty"`
+}
+
+// Version is a template version. Engine is the template
+// engine, handlebars if zero-length.
+type Version struct {
+	Tag       string `json:"tag"`       // "tag": "v0",
+	Template  string `json:"template"`  // "template": "{{fname}} {{lname}}",
+	Engine    string `json:"engine"`    // "engine": "handlebars",
+	Comment   string `json:"comment"`   // "comment": "version comment",
+	Active    bool   `json:"active"`    // "active": true,
+	CreatedAt string `json:"createdAt"` // "createdAt": "Wed, 29 Aug 2018 23:31:11 UTC",
+	ID        string `json:"id"`        // "id": "3efd2b85-0f41-4a1d-9898-05d7e7459c4a"
+}
+
+func (v *Version) form(req *client.Request) *client.Request {
+	if len(v.Template) > 0 {
+		req.SetForm(`template`, v.Template)
+	}
+	if len(v.Tag) > 0 {
+		req.SetForm(`tag`, v.Tag)
+	}
+	if len(v.Engine) > 0 {
+		req.SetForm(`engine`, v.Engine)
+	}
+	if len(v.Comment) > 0 {
+		req.SetForm(`comment`, v.Comment)
+	}
+	if v.Active {
+		req.SetForm(`active`, `yes`)
+	}
+	return req
+}
+
+// Templates contains Templates and paging info returned by queries.
+type Templates struct {
+	Templates []Template `json:"items"`
+	pager.Pager
+}
+
+// Next returns the next page.
+func (t *Templates) Next() (*Templates, error) {
+	var ts *Templates
+	return ts, t.Paging.Next(&ts)
+}
+
+// Previous returns the previous page.
+func (t *Templates) Previous() (*Templates, error) {
+	var ts *Templates
+	return ts, t.Paging.Previous(&ts)
+}
+
+// First returns the first page.
+func (t *Templates) First() (*Templates, error) {
+	var ts *Templates
+	return ts, t.Paging.First(&ts)
+}
+
+// Last returns the last page.
+func (t *Templates) Last() (*Templates, error) {
+	var ts *Templates
+	return ts, t.Paging.Last(&ts)
+}
+
+// All returns an iterator over the templates of t
+// and subsequent pages.
+func (t *Templates) All() iter.Seq2[Template, error] {
+	return pager.Seq(t.Templates, t.Paging)
+}
+
+// Create stores a template name with description. If v is
+// not nil it is the initial version of the template.
+func Create(c client.Caller, name, description string, v *Version) (*Template, error) {
+	req := c.Post(`templates`).SetForm(`name`, name)
+	if len(description) > 0 {
+		req.SetForm(`description`, description)
+	}
+	if v != nil {
+		v.form(req)
+	}
+	return client.DecodeJSON[*Template](req, `template`)
+}
+
+// List returns Templates for API domain.
+func List(c client.Caller) (*Templates, error) {
+	var ts *Templates
+	return ts, c.Get(`templates`).Decode(&ts)
+}
+
+// Get returns the template name, with its active
+// version if active is true.
+func Get(c client.Caller, name string, active bool) (*Template, error) {
+	req := c.Get(`templates`, name)
+	if active {
+		req.SetQuery(`active`, `yes`)
+	}
+	return client.DecodeJSON[*Template](req, `template`)
+}
+
+// Update updates the description of template name.
+func Update(c client.Caller, name, description string) error {
+	return c.Put(`templates`, name).SetForm(`description`, description).Err()
+}
+
+// Delete deletes template name and its versions.
+func Delete(c client.Caller, name string) error {
+	return c.Delete(`templates`, name).Err()
+}
+
+// DeleteAll deletes every template of API domain.
+func DeleteAll(c client.Caller) error {
+	return c.Delete(`templates`).Err()
+}
+
+// Versions contains the Versions of a template and paging
+// info returned by queries.
+type Versions struct {
+	Template struct {
+		Template
+		Versions []Version `json:"versions"`
+	} `json:"template"`
+	pager.Pager
+}
+
+// Next returns the next page.
+func (v *Versions) Next() (*Versions, error) {
+	var vs *Versions
+	return vs, v.Paging.Next(&vs)
+}
+
+// Previous returns the previous page.
+func (v *Versions) Previous() (*Versions, error) {
+	var vs *Versions
+	return vs, v.Paging.Previous(&vs)
+}
+
+// First returns the first page.
+func (v *Versions) First() (*Versions, error) {
+	var vs *Versions
+	return vs, v.Paging.First(&vs)
+}
+
+// Last returns the last page.
+func (v *Versions) Last() (*Versions, error) {
+	var vs *Versions
+	return vs, v.Paging.Last(&vs)
+}
+
+// All returns an iterator over the versions of v
+// and subsequent pages.
+func (v *Versions) All() iter.Seq2[Version, error] {
+	return pager.SeqFunc(v.Template.Versions, v.Paging, func(c client.Caller, uri string) ([]Version, *pager.Paging, error) {
+		var vs *Versions
+		if err := c.Get(uri).Decode(&vs); err != nil {
+			return nil, nil, err
+		}
+		return vs.Template.Versions, vs.Paging, nil
+	})
+}
+
+// ListVersions returns the Versions of template name.
+func ListVersions(c client.Caller, name string) (*Versions, error) {
+	var vs *Versions
+	return vs, c.Get(`templates`, name, `versions`).Decode(&vs)
+}
+
+// AddVersion adds version v to template name, which becomes
+// the active version if v.Active is true.
+func AddVersion(c client.Caller, name string, v Version) (*Template, error) {
+	return client.DecodeJSON[*Template](v.form(c.Post(`templates`, name, `versions`)), `template`)
+}
+
+// GetVersion returns version tag of template name.
+func GetVersion(c client.Caller, name, tag string) (*Version, error) {
+	t, err := client.GetJSON[*Template](c, `template`, `templates`, name, `versions`, tag)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	return t.Version, nil
+}
+
+// UpdateVersion updates the content and comment of version
+// v.Tag of template name, activating it if v.Active is true.
+func UpdateVersion(c client.Caller, name string, v Version) error {
+	req := c.Put(`templates`, name, `versions`, v.Tag)
+	if len(v.Template) > 0 {
+		req.SetForm(`template`, v.Template)
+	}
+	if len(v.Comment) > 0 {
+		req.SetForm(`comment`, v.Comment)
+	}
+	if v.Active {
+		req.SetForm(`active`, `yes`)
+	}
+	return req.Err()
+}
+
+// Activate makes version tag the active version of template name.
+func Activate(c client.Caller, name, tag string) error {
+	return c.Put(`templates`, name, `versions`, tag).SetForm(`active`, `yes`).Err()
+}
+
+// DeleteVersion deletes version tag of template name.
+func DeleteVersion(c client.Caller, name, tag string) error {
+	return c.Delete(`templates`, name, `versions`, tag).Err()
+}
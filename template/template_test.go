@@ -0,0 +1,70 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/j7b/mailgun/client/fake"
+)
+
+func TestTemplates(t *testing.T) {
+	c := fake.Client(t)
+	tmpl, err := Create(c, `welcome`, `welcome mail`, &Version{Tag: `v1`, Template: `<p>Hi {{name}}</p>`, Comment: `first`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Name != `welcome` || tmpl.Version == nil || !tmpl.Version.Active {
+		t.Fatal(`want welcome with active v1 got`, tmpl)
+	}
+	if _, err = Create(c, `other`, ``, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AddVersion(c, `welcome`, Version{Tag: `v2`, Template: `<p>Hello {{name}}</p>`}); err != nil {
+		t.Fatal(err)
+	}
+	if tmpl, err = Get(c, `welcome`, true); err != nil || tmpl.Version.Tag != `v1` {
+		t.Fatal(`want active v1 got`, tmpl, err)
+	}
+	if err = Activate(c, `welcome`, `v2`); err != nil {
+		t.Fatal(err)
+	}
+	if err = UpdateVersion(c, `welcome`, Version{Tag: `v1`, Comment: `old`}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := GetVersion(c, `welcome`, `v1`)
+	if err != nil || v.Active || v.Comment != `old` || v.Template != `<p>Hi {{name}}</p>` {
+		t.Fatal(`want inactive v1 got`, v, err)
+	}
+	vs, err := ListVersions(c, `welcome`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tags []string
+	for v, err := range vs.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, v.Tag)
+	}
+	if len(tags) != 2 || tags[1] != `v2` {
+		t.Fatal(`want v1 and v2 got`, tags)
+	}
+	if err = DeleteVersion(c, `welcome`, `v1`); err != nil {
+		t.Fatal(err)
+	}
+	if err = Update(c, `welcome`, `updated`); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := List(c)
+	if err != nil || len(ts.Templates) != 2 || ts.Templates[1].Description != `updated` {
+		t.Fatal(`want 2 templates got`, ts, err)
+	}
+	if err = Delete(c, `other`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Get(c, `other`, false); err == nil {
+		t.Fatal(`want not found`)
+	}
+	if err = DeleteAll(c); err != nil {
+		t.Fatal(err)
+	}
+}
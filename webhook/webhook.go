@@ -3,7 +3,11 @@
 Note docs say "Mailgun imposes a rate limit for the Webhook API endpoint.
 Users may issue no more than 300 requests per minute, per account."
 
-Callers with grave concerns about this should see bugs section.
+Callers with grave concerns about this should see bugs section,
+or limit requests with a client.Limiter, for example
+
+	requester.Limit = client.NewLimiter(0, 0).Family(client.Webhooks, 300, time.Minute)
+
 */
 package webhook
 